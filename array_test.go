@@ -0,0 +1,104 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type arrayConfig struct {
+	Shards   [3]string        `env:"SHARDS"`
+	Ports    [2]int           `env:"PORTS,separator=;"`
+	Timeouts [2]time.Duration `env:"TIMEOUTS,optional"`
+	Key      [2]byte          `env:"KEY,encoding=hex,optional"`
+}
+
+func TestArrays(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want arrayConfig
+		err  bool
+	}{
+		{
+			name: "values",
+			vars: map[string]string{"SHARDS": "a, b ,c", "PORTS": "80;443", "TIMEOUTS": "1s,1m", "KEY": "00ff"},
+			want: arrayConfig{
+				Shards: [3]string{"a", "b", "c"}, Ports: [2]int{80, 443},
+				Timeouts: [2]time.Duration{time.Second, time.Minute}, Key: [2]byte{0, 255},
+			},
+		},
+		{
+			name: "too few elements",
+			vars: map[string]string{"SHARDS": "a,b", "PORTS": "80;443"},
+			err:  true,
+		},
+		{
+			name: "too many elements",
+			vars: map[string]string{"SHARDS": "a,b,c", "PORTS": "80;443;8080"},
+			err:  true,
+		},
+		{
+			name: "invalid element",
+			vars: map[string]string{"SHARDS": "a,b,c", "PORTS": "80;http"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg arrayConfig
+			err := ParseFromMap(&cfg, tt.vars)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestArrayRoundTrip(t *testing.T) {
+	want := arrayConfig{
+		Shards: [3]string{"a,1", "b", "c"}, Ports: [2]int{80, 443},
+		Timeouts: [2]time.Duration{time.Second, 0}, Key: [2]byte{0xbe, 0xef},
+	}
+
+	vars, err := Marshal(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars["PORTS"] != "80;443" || vars["KEY"] != "beef" {
+		t.Errorf("unexpected vars %v", vars)
+	}
+
+	var got arrayConfig
+	if err := ParseFromMap(&got, vars); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("expected %+v, found %+v", want, got)
+	}
+}
+
+func TestArrayTypeByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want reflect.Type
+	}{
+		{name: "[3]int", want: reflect.TypeOf([3]int{})},
+		{name: "[2][]string", want: reflect.TypeOf([2][]string{})},
+		{name: "[0]bool", want: reflect.TypeOf([0]bool{})},
+		{name: "[-1]int"},
+		{name: "[x]int"},
+		{name: "[3]chan"},
+	}
+
+	for _, tt := range tests {
+		typ, ok := Options{}.typeByName(tt.name)
+		if ok != (tt.want != nil) || typ != tt.want {
+			t.Errorf("expected %v for '%s', found %v, %v", tt.want, tt.name, typ, ok)
+		}
+	}
+}
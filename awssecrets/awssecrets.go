@@ -0,0 +1,116 @@
+// Package awssecrets resolves variables from AWS Secrets Manager and SSM
+// Parameter Store.
+//
+// The package does not depend on the AWS SDK. Callers pass a small client,
+// usually a thin wrapper around the SDK's client:
+//
+//	type ssmClient struct{ c *ssm.Client }
+//
+//	func (s ssmClient) GetParameter(ctx context.Context, name string) (string, error) {
+//		out, err := s.c.GetParameter(ctx, &ssm.GetParameterInput{Name: &name, WithDecryption: aws.Bool(true)})
+//		var nf *types.ParameterNotFound
+//		if errors.As(err, &nf) {
+//			return "", awssecrets.ErrNotFound
+//		}
+//		if err != nil {
+//			return "", err
+//		}
+//		return *out.Parameter.Value, nil
+//	}
+package awssecrets
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reverted/env"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type SecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, secretID string) (string, error)
+}
+
+type ParameterStoreClient interface {
+	GetParameter(ctx context.Context, name string) (string, error)
+}
+
+type options struct {
+	prefix  string
+	mapName func(key string) string
+	ttl     time.Duration
+}
+
+type Option func(*options)
+
+// WithPrefix is prepended to the mapped name, e.g. "/myapp/prod/".
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithNameMapper transforms a variable name before the prefix is applied.
+func WithNameMapper(fn func(key string) string) Option {
+	return func(o *options) {
+		o.mapName = fn
+	}
+}
+
+// WithTTL sets how long values are cached, defaults to five minutes.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+func NewSecretsManager(client SecretsManagerClient, opts ...Option) env.ContextLookuper {
+	return newSource("aws-secretsmanager", client.GetSecretValue, opts)
+}
+
+func NewParameterStore(client ParameterStoreClient, opts ...Option) env.ContextLookuper {
+	return newSource("aws-ssm", client.GetParameter, opts)
+}
+
+func newSource(name string, get func(ctx context.Context, name string) (string, error), opts []Option) env.ContextLookuper {
+	o := options{ttl: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return env.Cache(&source{name: name, get: get, opts: o}, o.ttl)
+}
+
+type source struct {
+	name string
+	get  func(ctx context.Context, name string) (string, error)
+	opts options
+}
+
+func (s *source) Lookup(key string) (string, bool) {
+	value, ok, _ := s.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (s *source) SourceName() string {
+	return s.name
+}
+
+func (s *source) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	name := key
+	if s.opts.mapName != nil {
+		name = s.opts.mapName(name)
+	}
+
+	value, err := s.get(ctx, s.opts.prefix+name)
+	if errors.Is(err, ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
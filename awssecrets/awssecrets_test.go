@@ -0,0 +1,130 @@
+package awssecrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/reverted/env"
+)
+
+type fakeClient struct {
+	values map[string]string
+	err    error
+	calls  []string
+}
+
+func (c *fakeClient) GetSecretValue(ctx context.Context, secretID string) (string, error) {
+	return c.get(secretID)
+}
+
+func (c *fakeClient) GetParameter(ctx context.Context, name string) (string, error) {
+	return c.get(name)
+}
+
+func (c *fakeClient) get(name string) (string, error) {
+	c.calls = append(c.calls, name)
+	if c.err != nil {
+		return "", c.err
+	}
+	value, ok := c.values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func TestSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		new    func(c *fakeClient, opts ...Option) env.ContextLookuper
+		source string
+		opts   []Option
+		values map[string]string
+		key    string
+		want   string
+		ok     bool
+		call   string
+	}{
+		{
+			name:   "secrets manager",
+			new:    func(c *fakeClient, opts ...Option) env.ContextLookuper { return NewSecretsManager(c, opts...) },
+			source: "aws-secretsmanager",
+			values: map[string]string{"DB_PASSWORD": "hunter2"},
+			key:    "DB_PASSWORD",
+			want:   "hunter2",
+			ok:     true,
+			call:   "DB_PASSWORD",
+		},
+		{
+			name:   "parameter store prefix and mapper",
+			new:    func(c *fakeClient, opts ...Option) env.ContextLookuper { return NewParameterStore(c, opts...) },
+			source: "aws-ssm",
+			opts:   []Option{WithPrefix("/myapp/prod/"), WithNameMapper(strings.ToLower)},
+			values: map[string]string{"/myapp/prod/db_password": "hunter2"},
+			key:    "DB_PASSWORD",
+			want:   "hunter2",
+			ok:     true,
+			call:   "/myapp/prod/db_password",
+		},
+		{
+			name:   "not found",
+			new:    func(c *fakeClient, opts ...Option) env.ContextLookuper { return NewParameterStore(c, opts...) },
+			source: "aws-ssm",
+			key:    "MISSING",
+			call:   "MISSING",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{values: tt.values}
+			l := tt.new(client, tt.opts...)
+
+			for i := 0; i < 2; i++ {
+				value, ok, err := l.LookupContext(context.Background(), tt.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if value != tt.want || ok != tt.ok {
+					t.Errorf("expected '%s', %v, found '%s', %v", tt.want, tt.ok, value, ok)
+				}
+			}
+
+			// the second lookup is served from the cache, misses included
+			if len(client.calls) != 1 || client.calls[0] != tt.call {
+				t.Errorf("expected a single call for '%s', found %v", tt.call, client.calls)
+			}
+
+			if name := l.(env.NamedLookuper).SourceName(); name != tt.source {
+				t.Errorf("expected source '%s', found '%s'", tt.source, name)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errDenied := errors.New("access denied")
+	client := &fakeClient{err: errDenied}
+
+	type config struct {
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	l := NewSecretsManager(client)
+
+	var cfg config
+	err := env.ParseWithOptions(&cfg, env.WithLookuper(l))
+	if !errors.Is(err, errDenied) {
+		t.Fatalf("expected %v, found %v", errDenied, err)
+	}
+
+	// errors are not cached
+	if err := env.ParseWithOptions(&cfg, env.WithLookuper(l)); !errors.Is(err, errDenied) {
+		t.Fatalf("expected %v, found %v", errDenied, err)
+	}
+	if len(client.calls) != 2 {
+		t.Errorf("expected 2 calls, found %d", len(client.calls))
+	}
+}
@@ -0,0 +1,127 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type benchFlat struct {
+	A string `env:"A"`
+	B string `env:"B"`
+	C string `env:"C"`
+	D string `env:"D"`
+	E string `env:"E,optional"`
+	F int    `env:"F"`
+}
+
+type benchOne struct {
+	A string `env:"A"`
+}
+
+type benchEight struct {
+	A string `env:"A"`
+	B string `env:"B"`
+	C string `env:"C"`
+	D string `env:"D"`
+	E string `env:"E"`
+	F string `env:"F"`
+	G string `env:"G"`
+	H string `env:"H"`
+}
+
+var benchVars = Map{"A": "a", "B": "b", "C": "c", "D": "d", "E": "e", "F": "1", "G": "g", "H": "h"}
+
+func BenchmarkParseFlat(b *testing.B) {
+	opts := []Option{WithLookuper(benchVars), WithHermetic()}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg benchFlat
+		if err := ParseWithOptions(&cfg, opts...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSchemaParseFlat(b *testing.B) {
+	s, err := Compile[benchFlat](WithPrefix("APP_"), WithAutoNames(SnakeUpper), WithHermetic())
+	if err != nil {
+		b.Fatal(err)
+	}
+	vars := Map{"APP_A": "a", "APP_B": "b", "APP_C": "c", "APP_D": "d", "APP_F": "1"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Parse(vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseFlatOS(b *testing.B) {
+	for key, value := range benchVars {
+		b.Setenv(key, value)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg benchFlat
+		if err := Parse(&cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestParseStringFieldAllocs checks that string fields whose variables are set
+// cost no allocations: parsing eight of them allocates as much as one.
+func TestParseStringFieldAllocs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "lookuper", opts: []Option{WithLookuper(benchVars), WithHermetic()}},
+		{name: "vars", opts: []Option{WithVars(benchVars), WithHermetic()}},
+		{name: "os"},
+	}
+
+	for key, value := range benchVars {
+		t.Setenv(key, value)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			one := testing.AllocsPerRun(100, func() {
+				var cfg benchOne
+				if err := ParseWithOptions(&cfg, tt.opts...); err != nil {
+					t.Fatal(err)
+				}
+			})
+			eight := testing.AllocsPerRun(100, func() {
+				var cfg benchEight
+				if err := ParseWithOptions(&cfg, tt.opts...); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			if eight != one {
+				t.Errorf("expected no allocations per set string field, found %v for one field and %v for eight", one, eight)
+			}
+		})
+	}
+}
+
+func TestSetStringFieldAllocs(t *testing.T) {
+	d := newDecoder(context.Background(), Options{})
+	var s string
+	v := reflect.ValueOf(&s).Elem()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := d.setField(v, "value", Tag{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations setting a string field, found %v", allocs)
+	}
+}
@@ -0,0 +1,76 @@
+package env
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// compareBig compares a big.Int or big.Float against a bound, for the min
+// and max rules.
+func compareBig(v reflect.Value, arg string) (int, bool, error) {
+	switch v.Type() {
+	case bigIntType:
+		bound, ok := new(big.Int).SetString(arg, 10)
+		if !ok {
+			return 0, true, fmt.Errorf("invalid integer '%s'", arg)
+		}
+		x := v.Interface().(big.Int)
+		return x.Cmp(bound), true, nil
+
+	case bigFloatType:
+		bound, _, err := big.ParseFloat(arg, 10, 0, big.ToNearestEven)
+		if err != nil {
+			return 0, true, err
+		}
+		x := v.Interface().(big.Float)
+		return x.Cmp(bound), true, nil
+	}
+
+	return 0, false, nil
+}
+
+// rangeError replaces the range errors of strconv, which do not say what the
+// range is, with one naming the type. Negative values for unsigned types are
+// reported as out of range rather than as invalid syntax.
+func rangeError(v reflect.Value, value string, err error) error {
+	numErr, ok := err.(*strconv.NumError)
+	if !ok {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if numErr.Err == strconv.ErrRange {
+			min, max := int64(-1)<<(v.Type().Bits()-1), int64(1)<<(v.Type().Bits()-1)-1
+			return fmt.Errorf("value '%s' out of range for type '%s', expected %d to %d : %w", value, v.Type(), min, max, strconv.ErrRange)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		max := uint64(1)<<(v.Type().Bits()-1)<<1 - 1
+		_, signedErr := strconv.ParseInt(value, 10, 64)
+		negative := strings.HasPrefix(value, "-") && (signedErr == nil || isRangeError(signedErr))
+		if numErr.Err == strconv.ErrRange || negative {
+			return fmt.Errorf("value '%s' out of range for type '%s', expected 0 to %d : %w", value, v.Type(), max, strconv.ErrRange)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if numErr.Err == strconv.ErrRange {
+			return fmt.Errorf("value '%s' out of range for type '%s' : %w", value, v.Type(), strconv.ErrRange)
+		}
+	}
+
+	return err
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
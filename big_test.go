@@ -0,0 +1,108 @@
+package env
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBigNumbers(t *testing.T) {
+	type config struct {
+		Supply big.Int    `env:"SUPPLY,min=1,max=1000000000000000000000000"`
+		Price  *big.Float `env:"PRICE,optional,min=0.5"`
+	}
+
+	tests := []struct {
+		name   string
+		vars   Map
+		supply string
+		price  string
+		err    error
+	}{
+		{name: "values", vars: Map{"SUPPLY": "999999999999999999999999", "PRICE": "1.25"}, supply: "999999999999999999999999", price: "1.25"},
+		{name: "below min", vars: Map{"SUPPLY": "0"}, err: ErrValidation},
+		{name: "above max", vars: Map{"SUPPLY": "1000000000000000000000001"}, err: ErrValidation},
+		{name: "float below min", vars: Map{"SUPPLY": "1", "PRICE": "0.25"}, err: ErrValidation},
+		{name: "invalid", vars: Map{"SUPPLY": "1e3"}, err: ErrParse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic())
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, found %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := cfg.Supply.String(); got != tt.supply {
+				t.Errorf("expected supply %s, found %s", tt.supply, got)
+			}
+			if got := cfg.Price.Text('g', -1); got != tt.price {
+				t.Errorf("expected price %s, found %s", tt.price, got)
+			}
+		})
+	}
+}
+
+func TestMarshalBig(t *testing.T) {
+	type config struct {
+		Supply big.Int   `env:"SUPPLY"`
+		Price  big.Float `env:"PRICE"`
+	}
+
+	var cfg config
+	cfg.Supply.SetString("123456789012345678901234567890", 10)
+	cfg.Price.SetFloat64(0.5)
+
+	got, err := Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"SUPPLY": "123456789012345678901234567890", "PRICE": "0.5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, found %v", want, got)
+	}
+
+	for name, want := range map[string]reflect.Type{"big.Int": bigIntType, "big.Float": bigFloatType} {
+		if typ, ok := (Options{}).typeByName(name); !ok || typ != want {
+			t.Errorf("expected %v for '%s', found %v", want, name, typ)
+		}
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	tests := []struct {
+		typ   reflect.Type
+		value string
+		want  string
+	}{
+		{typ: reflect.TypeOf(int8(0)), value: "128", want: "value '128' out of range for type 'int8', expected -128 to 127 : value out of range"},
+		{typ: reflect.TypeOf(int16(0)), value: "-40000", want: "value '-40000' out of range for type 'int16', expected -32768 to 32767 : value out of range"},
+		{typ: reflect.TypeOf(uint8(0)), value: "256", want: "value '256' out of range for type 'uint8', expected 0 to 255 : value out of range"},
+		{typ: reflect.TypeOf(uint64(0)), value: "-1", want: "value '-1' out of range for type 'uint64', expected 0 to 18446744073709551615 : value out of range"},
+		{typ: reflect.TypeOf(uint(0)), value: "-99999999999999999999", want: "value '-99999999999999999999' out of range for type 'uint', expected 0 to 18446744073709551615 : value out of range"},
+		{typ: reflect.TypeOf(float32(0)), value: "1e39", want: "value '1e39' out of range for type 'float32' : value out of range"},
+		{typ: reflect.TypeOf(uint8(0)), value: "-x", want: `strconv.ParseUint: parsing "-x": invalid syntax`},
+		{typ: reflect.TypeOf(int8(0)), value: "x", want: `strconv.ParseInt: parsing "x": invalid syntax`},
+	}
+
+	for _, tt := range tests {
+		_, err := setValue(tt.typ, tt.value)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("expected %q for %s '%s', found %v", tt.want, tt.typ, tt.value, err)
+			continue
+		}
+		if isRange := errors.Is(err, strconv.ErrRange); isRange != strings.Contains(tt.want, "out of range") {
+			t.Errorf("expected the error for %s '%s' to wrap strconv.ErrRange: %v", tt.typ, tt.value, isRange)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package env
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+var (
+	urlType   = reflect.TypeOf(url.URL{})
+	ipNetType = reflect.TypeOf(net.IPNet{})
+	timeType  = reflect.TypeOf(time.Time{})
+)
+
+// timeLayouts are the layouts of the time package that the layout tag option
+// accepts by name, since most contain commas or spaces.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+func timeLayout(layout string) string {
+	if named, ok := timeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+// builtinParsers cover standard library types that do not implement
+// encoding.TextUnmarshaler. They can be replaced with RegisterParser.
+func builtinParsers() map[reflect.Type]ParserFunc {
+	return map[reflect.Type]ParserFunc{
+		urlType:   parseURL,
+		ipNetType: parseIPNet,
+	}
+}
+
+func parseURL(value string) (interface{}, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("invalid URL '%s' : missing scheme", value)
+	}
+	return *u, nil
+}
+
+func parseIPNet(value string) (interface{}, error) {
+	_, n, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, err
+	}
+	return *n, nil
+}
+
+// formatBuiltin formats the types handled by builtinParsers.
+func formatBuiltin(v reflect.Value) (string, bool) {
+	switch v.Type() {
+	case urlType:
+		u := v.Interface().(url.URL)
+		return u.String(), true
+
+	case ipNetType:
+		n := v.Interface().(net.IPNet)
+		return n.String(), true
+	}
+
+	return "", false
+}
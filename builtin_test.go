@@ -0,0 +1,79 @@
+package env
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNetworkFields(t *testing.T) {
+	u := url.URL{Scheme: "https", Host: "example.com:8443", Path: "/api"}
+	_, cidr, _ := net.ParseCIDR("10.0.0.0/8")
+
+	tests := []struct {
+		typ   reflect.Type
+		tests []setValueTest
+	}{
+		{reflect.TypeOf(url.URL{}), []setValueTest{{value: "https://example.com:8443/api", want: u}, {value: "example.com", err: true}, {value: "http://[::1", err: true}}},
+		{reflect.TypeOf((*url.URL)(nil)), []setValueTest{{value: "https://example.com:8443/api", want: &u}}},
+		{reflect.TypeOf(net.IP{}), []setValueTest{{value: "192.168.0.1", want: net.ParseIP("192.168.0.1")}, {value: "::1", want: net.ParseIP("::1")}, {value: "256.0.0.1", err: true}}},
+		{reflect.TypeOf(net.IPNet{}), []setValueTest{{value: "10.1.2.3/8", want: *cidr}, {value: "10.0.0.0", err: true}}},
+		{reflect.TypeOf((*net.IPNet)(nil)), []setValueTest{{value: "10.0.0.0/8", want: cidr}}},
+		{reflect.TypeOf(netip.Addr{}), []setValueTest{{value: "fe80::1", want: netip.MustParseAddr("fe80::1")}, {value: "fe80::g", err: true}}},
+		{reflect.TypeOf(netip.Prefix{}), []setValueTest{{value: "10.0.0.0/8", want: netip.MustParsePrefix("10.0.0.0/8")}, {value: "10.0.0.0/33", err: true}}},
+		{reflect.TypeOf(netip.AddrPort{}), []setValueTest{{value: "127.0.0.1:80", want: netip.MustParseAddrPort("127.0.0.1:80")}}},
+		{reflect.TypeOf([]netip.Addr(nil)), []setValueTest{{value: "127.0.0.1,::1", want: []netip.Addr{netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("::1")}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			testSetValue(t, tt.typ, tt.tests)
+		})
+	}
+}
+
+func TestNetworkErrors(t *testing.T) {
+	tests := []struct {
+		typ   reflect.Type
+		value string
+	}{
+		{reflect.TypeOf(url.URL{}), "example.com"},
+		{reflect.TypeOf(net.IP{}), "256.0.0.1"},
+		{reflect.TypeOf(net.IPNet{}), "10.0.0.0/40"},
+		{reflect.TypeOf(netip.Addr{}), "fe80::g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: tt.typ, Tag: `env:"V"`}})
+
+			err := ParseFromMap(reflect.New(typ).Interface(), map[string]string{"V": tt.value})
+			if err == nil || !strings.Contains(err.Error(), tt.value) {
+				t.Errorf("expected an error showing '%s', found %v", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestFormatBuiltin(t *testing.T) {
+	tests := []struct {
+		typ   reflect.Type
+		value string
+	}{
+		{reflect.TypeOf(url.URL{}), "https://example.com/a?b=c"},
+		{reflect.TypeOf(net.IPNet{}), "10.0.0.0/8"},
+	}
+
+	for _, tt := range tests {
+		v, err := setValue(tt.typ, tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := formatBuiltin(reflect.ValueOf(v)); !ok || got != tt.value {
+			t.Errorf("expected '%s', found '%s'", tt.value, got)
+		}
+	}
+}
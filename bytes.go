@@ -0,0 +1,153 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Bytes is a byte count parsed from sizes like 512kb or 10MiB, where kb, mb
+// and so on are powers of ten and kib, mib and so on powers of two. Unlike
+// ParseBytes, it accepts negative sizes such as -1KiB, so every value String
+// returns reads back.
+type Bytes int64
+
+func (b *Bytes) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	negative := strings.HasPrefix(s, "-")
+
+	n, err := ParseBytes(strings.TrimPrefix(s, "-"))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case negative && n > 1<<63:
+		return fmt.Errorf("size '%s' out of range", text)
+	case negative:
+		// 1<<63 wraps to math.MinInt64, which negates to itself
+		*b = Bytes(-int64(n))
+	case n > math.MaxInt64:
+		return fmt.Errorf("size '%s' out of range", text)
+	default:
+		*b = Bytes(n)
+	}
+	return nil
+}
+
+func (b Bytes) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// String uses the largest power of two unit that represents b exactly.
+func (b Bytes) String() string {
+	n := int64(b)
+	for i := len(binaryUnits) - 1; i >= 0; i-- {
+		size := int64(1) << (10 * (i + 1))
+		if n != 0 && n%size == 0 {
+			return strconv.FormatInt(n/size, 10) + binaryUnits[i]
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
+
+var binaryUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+var byteUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"e":   1e18,
+	"eb":  1e18,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+	"ei":  1 << 60,
+	"eib": 1 << 60,
+}
+
+// ParseBytes parses a size such as 512kb, 1.5GiB or 1024. Units are case
+// insensitive and may be separated from the number by spaces.
+func ParseBytes(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("negative size '%s'", s)
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+
+	mult, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit '%s'", s[i:])
+	}
+
+	if n, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if n > math.MaxUint64/mult {
+			return 0, fmt.Errorf("size '%s' out of range", s)
+		}
+		return n * mult, nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+
+	n := f * float64(mult)
+	if n >= math.MaxUint64 {
+		return 0, fmt.Errorf("size '%s' out of range", s)
+	}
+	return uint64(n), nil
+}
+
+// setBytes stores a size into an integer field tagged unit=bytes.
+func setBytes(v reflect.Value, value string) error {
+	n, err := ParseBytes(value)
+	if err != nil {
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n > math.MaxInt64 || v.OverflowInt(int64(n)) {
+			return fmt.Errorf("size '%s' out of range", value)
+		}
+		v.SetInt(int64(n))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.OverflowUint(n) {
+			return fmt.Errorf("size '%s' out of range", value)
+		}
+		v.SetUint(n)
+
+	default:
+		return ErrUnsupported
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package env
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		s    string
+		want uint64
+		err  bool
+	}{
+		{s: "0", want: 0},
+		{s: "1024", want: 1024},
+		{s: "512kb", want: 512e3},
+		{s: "10MiB", want: 10 << 20},
+		{s: "1.5 GiB", want: 3 << 29},
+		{s: " 2 K ", want: 2e3},
+		{s: "18446744073709551615", want: math.MaxUint64},
+		{s: "16EiB", err: true},
+		{s: "1XB", err: true},
+		{s: "abc", err: true},
+		{s: "-1KiB", err: true},
+		{s: "", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBytes(tt.s)
+		if (err != nil) != tt.err {
+			t.Errorf("unexpected error for %q : %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expected %q to be %d, found %d", tt.s, tt.want, got)
+		}
+	}
+}
+
+func TestBytesRoundTrip(t *testing.T) {
+	tests := []struct {
+		b    Bytes
+		want string
+	}{
+		{b: 0, want: "0B"},
+		{b: 1000, want: "1000B"},
+		{b: 1 << 10, want: "1KiB"},
+		{b: 3 << 20, want: "3MiB"},
+		{b: -1 << 10, want: "-1KiB"},
+		{b: -5, want: "-5B"},
+		{b: math.MaxInt64, want: "9223372036854775807B"},
+		{b: math.MinInt64, want: "-8EiB"},
+	}
+
+	for _, tt := range tests {
+		text, err := tt.b.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(text) != tt.want {
+			t.Errorf("expected %d to be written as %q, found %q", tt.b, tt.want, text)
+		}
+
+		var b Bytes
+		if err := b.UnmarshalText(text); err != nil {
+			t.Errorf("error reading back %q : %s", text, err)
+		}
+		if b != tt.b {
+			t.Errorf("expected %q to read back as %d, found %d", text, tt.b, b)
+		}
+	}
+}
+
+func TestBytesUnmarshalRange(t *testing.T) {
+	for _, s := range []string{"8EiB", "-9EiB", "--1KiB", "-"} {
+		var b Bytes
+		if err := b.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("expected an error for %q, found %d", s, b)
+		}
+	}
+}
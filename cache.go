@@ -0,0 +1,61 @@
+package env
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value   string
+	ok      bool
+	expires time.Time
+}
+
+type cache struct {
+	l   Lookuper
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// Cache remembers the results of l, including misses, for ttl. A ttl of zero
+// caches results forever. Errors are never cached.
+func Cache(l Lookuper, ttl time.Duration) ContextLookuper {
+	return &cache{
+		l:       l,
+		ttl:     ttl,
+		entries: map[string]cacheEntry{},
+	}
+}
+
+func (c *cache) Lookup(key string) (string, bool) {
+	value, ok, _ := c.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (c *cache) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	entry, hit := c.entries[key]
+	c.mu.Unlock()
+
+	if hit && (c.ttl == 0 || time.Now().Before(entry.expires)) {
+		return entry.value, entry.ok, nil
+	}
+
+	value, ok, err := lookupContext(ctx, c.l, key)
+	if err != nil {
+		return "", false, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{value: value, ok: ok, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return value, ok, nil
+}
+
+func (c *cache) SourceName() string {
+	return sourceName(c.l)
+}
@@ -0,0 +1,66 @@
+package env
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wait    time.Duration
+		lookups int
+	}{
+		{name: "forever", ttl: 0, lookups: 1},
+		{name: "within ttl", ttl: time.Hour, lookups: 1},
+		{name: "expired", ttl: time.Millisecond, wait: 5 * time.Millisecond, lookups: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &conditionLookuper{vars: Map{"HOST": "h"}}
+			c := Cache(l, tt.ttl)
+
+			for _, key := range []string{"HOST", "MISSING"} {
+				for i := 0; i < 2; i++ {
+					if i == 1 {
+						time.Sleep(tt.wait)
+					}
+
+					value, ok, err := c.LookupContext(context.Background(), key)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if want := l.vars[key]; value != want || ok != (want != "") {
+						t.Errorf("expected '%s', found '%s', %v", want, value, ok)
+					}
+				}
+			}
+
+			// hits and misses are both cached
+			if len(l.looked) != 2*tt.lookups {
+				t.Errorf("expected %d lookups, found %v", 2*tt.lookups, l.looked)
+			}
+		})
+	}
+}
+
+func TestCacheErrors(t *testing.T) {
+	l := &conditionLookuper{vars: Map{}, fail: map[string]bool{"HOST": true}}
+	c := Cache(Named("remote", l), 0)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.LookupContext(context.Background(), "HOST"); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if len(l.looked) != 2 {
+		t.Errorf("expected errors not to be cached, found %v", l.looked)
+	}
+
+	if name := sourceName(c); name != "remote" {
+		t.Errorf("expected the name of the cached source, found '%s'", name)
+	}
+}
@@ -0,0 +1,80 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Check evaluates the environment against the type of obj without setting
+// anything, for readiness checks. It returns the required variables that are
+// missing and the fields whose variables do not hold valid values, while err
+// reports problems with the struct itself or with looking values up. Fields
+// are checked as if obj were zero, so existing values are not kept.
+func Check(obj interface{}, opts ...Option) (missing []string, invalid []FieldError, err error) {
+	o := newOptions(opts)
+	o.ExportResolved, o.UnsetAfterRead, o.OnSet = false, false, nil
+
+	if err := o.load(); err != nil {
+		return nil, nil, err
+	}
+
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("cannot check '%T' : expected a struct", obj)
+	}
+
+	if err := o.conflicts(t); err != nil {
+		return nil, nil, err
+	}
+
+	v := reflect.New(t).Elem()
+	d := newDecoder(context.Background(), o)
+
+	errs := d.parseStruct(v, scope{prefix: o.Prefix})
+	if o.Strict {
+		errs = append(errs, d.unknown()...)
+	}
+	if len(errs) == 0 {
+		errs = d.validateStruct(v, "")
+	}
+
+	var other []error
+	for _, err := range errs {
+		var missingErr MissingError
+		if errors.As(err, &missingErr) {
+			missing = append(missing, missingErr.Env)
+			continue
+		}
+
+		if fieldErr, ok := asFieldError(err); ok {
+			invalid = append(invalid, fieldErr)
+			continue
+		}
+
+		other = append(other, err)
+	}
+
+	return missing, invalid, errors.Join(other...)
+}
+
+// asFieldError reports errors about the value of a single variable or field.
+func asFieldError(err error) (FieldError, bool) {
+	switch e := err.(type) {
+	case ParseError:
+		return FieldError{Env: e.Env, Field: e.Field, Err: err}, true
+	case RuleError:
+		return FieldError{Env: e.Env, Field: e.Field, Err: err}, true
+	case EmptyError:
+		return FieldError{Env: e.Env, Field: e.Field, Err: err}, true
+	case UnknownError:
+		return FieldError{Env: e.Env, Err: err}, true
+	case ValidationError:
+		return FieldError{Field: e.Field, Err: err}, true
+	}
+	return FieldError{}, false
+}
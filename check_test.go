@@ -0,0 +1,154 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type checkConfig struct {
+	Host  string       `env:"HOST"`
+	Port  int          `env:"PORT,min=1"`
+	Name  string       `env:"NAME,notEmpty,optional"`
+	Token string       `env:"TOKEN"`
+	Valid ValidPointer `envPrefix:"VALID_"`
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    Map
+		opts    []Option
+		missing []string
+		invalid []FieldError
+	}{
+		{
+			name: "valid",
+			vars: Map{"HOST": "localhost", "PORT": "80", "TOKEN": "t"},
+		},
+		{
+			name:    "missing",
+			vars:    Map{"PORT": "80"},
+			missing: []string{"HOST", "TOKEN"},
+		},
+		{
+			name:    "invalid",
+			vars:    Map{"HOST": "localhost", "PORT": "0", "NAME": "", "TOKEN": "t"},
+			invalid: []FieldError{{Env: "PORT", Field: "Port"}, {Env: "NAME", Field: "Name"}},
+		},
+		{
+			name:    "missing and invalid",
+			vars:    Map{"PORT": "http", "TOKEN": "t"},
+			missing: []string{"HOST"},
+			invalid: []FieldError{{Env: "PORT", Field: "Port"}},
+		},
+		{
+			name:    "validate",
+			vars:    Map{"HOST": "localhost", "PORT": "80", "TOKEN": "t", "VALID_NAME": "invalid"},
+			invalid: []FieldError{{Field: "Valid"}},
+		},
+		{
+			name:    "strict",
+			vars:    Map{"APP_HOST": "localhost", "APP_PORT": "80", "APP_TOKEN": "t", "APP_HOTS": "x"},
+			opts:    []Option{WithPrefix("APP_"), WithStrict()},
+			invalid: []FieldError{{Env: "APP_HOTS"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, invalid, err := Check(&checkConfig{}, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(missing, tt.missing) {
+				t.Errorf("expected missing %q, found %q", tt.missing, missing)
+			}
+
+			if len(invalid) != len(tt.invalid) {
+				t.Fatalf("expected invalid %+v, found %+v", tt.invalid, invalid)
+			}
+			for i, want := range tt.invalid {
+				if invalid[i].Env != want.Env || invalid[i].Field != want.Field || invalid[i].Err == nil {
+					t.Errorf("expected invalid %+v, found %+v", want, invalid[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckSideEffects(t *testing.T) {
+	unsetenv(t, "CHECK_HOST")
+	t.Setenv("CHECK_PORT", "80")
+
+	type config struct {
+		Host string `env:"CHECK_HOST,default=localhost"`
+		Port int    `env:"CHECK_PORT"`
+	}
+
+	cfg := config{Host: "existing"}
+	called := false
+	opts := []Option{
+		WithExportResolved(), WithUnsetAfterRead(), WithKeepExisting(),
+		WithOnSet(func(FieldInfo, string, string) { called = true }),
+	}
+
+	missing, invalid, err := Check(&cfg, opts...)
+	if err != nil || len(missing) > 0 || len(invalid) > 0 {
+		t.Fatalf("unexpected result %q, %+v, %v", missing, invalid, err)
+	}
+
+	if cfg.Host != "existing" || cfg.Port != 0 {
+		t.Errorf("expected the config to be left alone, found %+v", cfg)
+	}
+	if called {
+		t.Error("expected OnSet not to be called")
+	}
+	if _, ok := os.LookupEnv("CHECK_HOST"); ok {
+		t.Error("expected CHECK_HOST not to be exported")
+	}
+	if port := os.Getenv("CHECK_PORT"); port != "80" {
+		t.Errorf("expected CHECK_PORT to be kept, found '%s'", port)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	type conflicting struct {
+		A string `env:"A"`
+		B string `env:"A"`
+	}
+	type failing struct {
+		Host string `env:"HOST"`
+	}
+
+	tests := []struct {
+		name string
+		obj  interface{}
+		opts []Option
+		err  error
+	}{
+		{name: "nil", obj: nil},
+		{name: "not a struct", obj: new(string)},
+		{name: "conflict", obj: conflicting{}, err: ErrConflict},
+		{name: "strict without prefix", obj: &failing{}, opts: []Option{WithStrict()}},
+		{
+			name: "lookup",
+			obj:  &failing{},
+			opts: []Option{WithLookuper(&conditionLookuper{vars: Map{}, fail: map[string]bool{"HOST": true}})},
+			err:  ErrLookup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Check(tt.obj, tt.opts...)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, found %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Command envcheck verifies an environment against the schema of a program,
+// as written by env.WriteSchema, so a deploy can fail before the program
+// starts.
+//
+//	envcheck -schema schema.json -dotenv .env -prefix MYAPP_ -strict
+//
+// Every missing, invalid or unknown variable is printed and the exit status
+// is 1 if there are any.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/reverted/env"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run checks the environment as described by args and returns the exit
+// status.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("envcheck", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	schema := fs.String("schema", "", "schema file written by env.WriteSchema, - for stdin")
+	prefix := fs.String("prefix", "", "prefix of the program's variables, used by -strict")
+	strict := fs.Bool("strict", false, "report variables under -prefix that the schema does not use")
+	useEnv := fs.Bool("env", true, "check the process environment, set to false to only check the -dotenv files")
+
+	var dotenv []string
+	fs.Func("dotenv", "dotenv file to layer beneath the environment, may be repeated", func(path string) error {
+		dotenv = append(dotenv, path)
+		return nil
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *schema == "" {
+		fs.Usage()
+		return 2
+	}
+	if *strict && *prefix == "" {
+		fmt.Fprintln(stderr, "envcheck: -strict requires -prefix")
+		return 2
+	}
+
+	specs, err := readSchema(*schema)
+	if err != nil {
+		fmt.Fprintf(stderr, "envcheck: error reading schema '%s' : %s\n", *schema, err)
+		return 2
+	}
+
+	opts := []env.Option{env.WithPrefix(*prefix), env.WithDotenv(dotenv...)}
+	if *strict {
+		opts = append(opts, env.WithStrict())
+	}
+	if !*useEnv {
+		opts = append(opts, env.WithLookuper(env.Map{}))
+	}
+
+	err = env.Verify(specs, opts...)
+	if err == nil {
+		return 0
+	}
+
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		for _, err := range joined.Unwrap() {
+			fmt.Fprintln(stdout, err)
+		}
+	} else {
+		fmt.Fprintln(stdout, err)
+	}
+	return 1
+}
+
+func readSchema(path string) ([]env.VarSpec, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return env.ReadSchema(r)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reverted/env"
+)
+
+type config struct {
+	Host string `env:"MYAPP_HOST"`
+	Port int    `env:"MYAPP_PORT,default=80"`
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+
+	schema := filepath.Join(dir, "schema.json")
+	f, err := os.Create(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.WriteSchema(f, &config{}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	dotenv := filepath.Join(dir, ".env")
+	if err := os.WriteFile(dotenv, []byte("MYAPP_HOST=dotenv\nMYAPP_PROT=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		env    map[string]string
+		status int
+		output []string
+	}{
+		{name: "no schema", status: 2},
+		{name: "missing schema", args: []string{"-schema", filepath.Join(dir, "missing.json")}, status: 2},
+		{name: "valid", args: []string{"-schema", schema}, env: map[string]string{"MYAPP_HOST": "h"}},
+		{name: "missing", args: []string{"-schema", schema}, status: 1, output: []string{"MYAPP_HOST"}},
+		{name: "invalid", args: []string{"-schema", schema}, env: map[string]string{"MYAPP_HOST": "h", "MYAPP_PORT": "http"}, status: 1, output: []string{"MYAPP_PORT"}},
+		{name: "dotenv", args: []string{"-schema", schema, "-dotenv", dotenv}},
+		{name: "dotenv only", args: []string{"-schema", schema, "-env=false"}, env: map[string]string{"MYAPP_HOST": "h"}, status: 1, output: []string{"MYAPP_HOST"}},
+		{name: "strict", args: []string{"-schema", schema, "-dotenv", dotenv, "-prefix", "MYAPP_", "-strict"}, status: 1, output: []string{"unknown env 'MYAPP_PROT'"}},
+		{name: "strict without prefix", args: []string{"-schema", schema, "-strict"}, status: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"MYAPP_HOST", "MYAPP_PORT", "MYAPP_PROT"} {
+				t.Setenv(key, "")
+				os.Unsetenv(key)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var stdout, stderr bytes.Buffer
+			if status := run(tt.args, &stdout, &stderr); status != tt.status {
+				t.Fatalf("expected status %d, found %d : %s%s", tt.status, status, stdout.String(), stderr.String())
+			}
+
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			if len(tt.output) == 0 && stdout.Len() > 0 {
+				t.Errorf("unexpected output %q", stdout.String())
+			}
+			for i, want := range tt.output {
+				if i >= len(lines) || !strings.Contains(lines[i], want) {
+					t.Errorf("expected line %d to mention '%s', found %q", i, want, stdout.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/reverted/env"
+)
+
+type kind struct {
+	name string // Go type as written, e.g. int32 or time.Duration
+	bits int
+	base string // string, bool, int, uint, float or duration
+}
+
+var kinds = map[string]kind{
+	"string":        {name: "string", base: "string"},
+	"bool":          {name: "bool", base: "bool"},
+	"int":           {name: "int", base: "int"},
+	"int8":          {name: "int8", bits: 8, base: "int"},
+	"int16":         {name: "int16", bits: 16, base: "int"},
+	"int32":         {name: "int32", bits: 32, base: "int"},
+	"rune":          {name: "rune", bits: 32, base: "int"},
+	"int64":         {name: "int64", bits: 64, base: "int"},
+	"uint":          {name: "uint", base: "uint"},
+	"uint8":         {name: "uint8", bits: 8, base: "uint"},
+	"byte":          {name: "byte", bits: 8, base: "uint"},
+	"uint16":        {name: "uint16", bits: 16, base: "uint"},
+	"uint32":        {name: "uint32", bits: 32, base: "uint"},
+	"uint64":        {name: "uint64", bits: 64, base: "uint"},
+	"float32":       {name: "float32", bits: 32, base: "float"},
+	"float64":       {name: "float64", bits: 64, base: "float"},
+	"time.Duration": {name: "time.Duration", base: "duration"},
+}
+
+type generator struct {
+	pkg     *pkg
+	buf     bytes.Buffer
+	imports map[string]bool
+
+	// validations holds the values to validate once every field is set,
+	// nested structs before the structs holding them.
+	validations []validation
+}
+
+type validation struct {
+	target string
+	path   string
+}
+
+func generate(p *pkg, types []string) ([]byte, error) {
+	g := &generator{pkg: p, imports: map[string]bool{"errors": true, "os": true}}
+
+	var body bytes.Buffer
+	for _, name := range types {
+		if err := g.function(&body, name); err != nil {
+			return nil, err
+		}
+	}
+
+	paths := make([]string, 0, len(g.imports)+1)
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	paths = append(paths, "github.com/reverted/env")
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by envgen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", p.name)
+	for _, path := range paths {
+		if strings.Contains(path, ".") {
+			fmt.Fprintf(&out, "\n")
+		}
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	fmt.Fprintf(&out, ")\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated code : %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) function(w *bytes.Buffer, name string) error {
+	st, ok := g.pkg.types[name]
+	if !ok {
+		return fmt.Errorf("struct type '%s' not found", name)
+	}
+
+	g.buf.Reset()
+	g.validations = nil
+	if err := g.fields(st, "c", "", "", g.pkg.declares(name, "Validate")); err != nil {
+		return fmt.Errorf("type '%s' : %w", name, err)
+	}
+	if g.pkg.declares(name, "Validate") {
+		g.validations = append(g.validations, validation{target: "c"})
+	}
+	g.validate()
+
+	fmt.Fprintf(w, `
+func Parse%[1]sFromEnv() (%[1]s, error) {
+	return Parse%[1]sFromLookup(os.LookupEnv)
+}
+
+func Parse%[1]sFromLookup(lookup func(string) (string, bool)) (%[1]s, error) {
+	var c %[1]s
+	var errs []error
+
+	get := func(names ...string) (string, string, bool) {
+		for _, name := range names {
+			if value, ok := lookup(name); ok {
+				return name, value, true
+			}
+		}
+		return names[0], "", false
+	}
+	_ = get
+
+%[2]s
+	return c, errors.Join(errs...)
+}
+`, name, g.buf.String())
+
+	return nil
+}
+
+// fields emits the code for every field of st, mirroring how env.Parse walks
+// nested structs. shadowed reports whether st, or a struct embedding it,
+// declares a Validate method that replaces those of its embedded structs.
+func (g *generator) fields(st *ast.StructType, target, path, prefix string, shadowed bool) error {
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(raw)
+		}
+
+		names := f.Names
+		anonymous := len(names) == 0
+		if anonymous {
+			names = []*ast.Ident{ast.NewIdent(typeName(f.Type))}
+		}
+
+		for _, ident := range names {
+			raw, hasTag := tag.Lookup(env.TagName)
+			if raw == "-" {
+				continue
+			}
+
+			// unexported embedded structs are flattened like Parse does
+			if !ident.IsExported() && (!anonymous || hasTag) {
+				continue
+			}
+
+			fieldTarget := target + "." + ident.Name
+			fieldPath := path
+			if !anonymous {
+				fieldPath = joinPath(path, ident.Name)
+			}
+
+			if !hasTag {
+				if _, ok := tag.Lookup(env.PrefixTagName); ok {
+					switch t := f.Type.(type) {
+					case *ast.ArrayType:
+						if g.nested(st, t.Elt) {
+							return fmt.Errorf("field '%s' : indexed fields are not supported", joinPath(path, ident.Name))
+						}
+					case *ast.MapType:
+						if g.nested(st, t.Value) {
+							return fmt.Errorf("field '%s' : keyed fields are not supported", joinPath(path, ident.Name))
+						}
+					}
+				}
+				if g.pkg.external(st, f.Type) {
+					return fmt.Errorf("field '%s' : nested structs declared in other packages are not supported, tag it with env:\"-\" to skip it", joinPath(path, ident.Name))
+				}
+
+				name := typeName(f.Type)
+				if !g.nested(st, f.Type) {
+					continue
+				}
+				if _, ok := f.Type.(*ast.StarExpr); ok {
+					return fmt.Errorf("field '%s' : pointers to nested structs are not supported", joinPath(path, ident.Name))
+				}
+				declared := g.pkg.declares(name, "Validate")
+				if err := g.fields(g.pkg.types[name], fieldTarget, fieldPath, prefix+tag.Get(env.PrefixTagName), declared || anonymous && shadowed); err != nil {
+					return err
+				}
+				if declared && !(anonymous && shadowed) {
+					g.validations = append(g.validations, validation{target: fieldTarget, path: fieldPath})
+				}
+				continue
+			}
+
+			fieldPath = joinPath(path, ident.Name)
+
+			parsed, _, err := env.ParseTag(tag)
+			if err != nil {
+				return fmt.Errorf("error parsing tag of field '%s' : %w", fieldPath, err)
+			}
+			if err := g.field(f.Type, parsed, fieldTarget, fieldPath, prefix); err != nil {
+				return fmt.Errorf("field '%s' : %w", fieldPath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// nested reports whether expr, used in st, names a struct env.Parse descends
+// into rather than converting it as a whole.
+func (g *generator) nested(st *ast.StructType, expr ast.Expr) bool {
+	if g.pkg.external(st, expr) {
+		return true
+	}
+	name := typeName(expr)
+	if _, ok := g.pkg.types[name]; !ok {
+		return false
+	}
+	return !g.pkg.declares(name, "SetEnv") && !g.pkg.declares(name, "UnmarshalText")
+}
+
+// validate emits the Validate calls, which like env.Parse only run when
+// every variable was read without error.
+func (g *generator) validate() {
+	if len(g.validations) == 0 {
+		return
+	}
+
+	w := &g.buf
+	fmt.Fprintf(w, "\tif len(errs) == 0 {\n")
+	for _, v := range g.validations {
+		fmt.Fprintf(w, "\t\tif err := %s.Validate(); err != nil {\n", v.target)
+		fmt.Fprintf(w, "\t\t\terrs = append(errs, env.ValidationError{Field: %q, Err: err})\n\t\t}\n", v.path)
+	}
+	fmt.Fprintf(w, "\t}\n\n")
+}
+
+func (g *generator) field(expr ast.Expr, tag env.Tag, target, path, prefix string) error {
+	if err := supported(tag); err != nil {
+		return err
+	}
+	if tag.Env == "" {
+		return fmt.Errorf("missing variable name in tag '%s'", env.TagName)
+	}
+
+	names := make([]string, 0, len(tag.Names()))
+	for _, name := range tag.Names() {
+		names = append(names, strconv.Quote(prefix+name))
+	}
+
+	var setter bytes.Buffer
+	usesName, err := g.setter(&setter, expr, tag, target, path)
+	if err != nil {
+		return err
+	}
+
+	nameVar, okVar := "_", "_"
+	if usesName || tag.NotEmpty {
+		nameVar = "name"
+	}
+	if tag.NotEmpty || tag.AllowEmpty {
+		okVar = "ok"
+	}
+
+	w := &g.buf
+	fmt.Fprintf(w, "\t// %s\n\t{\n", path)
+	fmt.Fprintf(w, "\t\t%s, value, %s := get(%s)\n", nameVar, okVar, strings.Join(names, ", "))
+
+	if !tag.NotEmpty && !tag.AllowEmpty && tag.Default != "" {
+		fmt.Fprintf(w, "\t\tif value == \"\" {\n\t\t\tvalue = %q\n\t\t}\n", tag.Default)
+		w.Write(setter.Bytes())
+		fmt.Fprintf(w, "\t}\n\n")
+		return nil
+	}
+
+	fmt.Fprintf(w, "\t\tswitch {\n")
+
+	switch {
+	case tag.NotEmpty:
+		fmt.Fprintf(w, "\t\tcase ok && value == \"\":\n\t\t\terrs = append(errs, env.EmptyError{Env: name, Field: %q%s})\n", path, description(tag))
+	case tag.AllowEmpty:
+		fmt.Fprintf(w, "\t\tcase ok && value == \"\":\n\t\t\t// set but empty, left at the zero value\n")
+	}
+
+	if tag.Default == "" {
+		fmt.Fprintf(w, "\t\tcase value == \"\":\n")
+		if tag.Optional {
+			fmt.Fprintf(w, "\t\t\t// optional\n")
+		} else {
+			aliases := ""
+			if len(tag.Aliases) > 0 {
+				aliases = fmt.Sprintf(", Aliases: []string{%s}", strings.Join(names[1:], ", "))
+			}
+			fmt.Fprintf(w, "\t\t\terrs = append(errs, env.MissingError{Env: %s, Field: %q%s%s})\n", names[0], path, aliases, description(tag))
+		}
+	}
+
+	fmt.Fprintf(w, "\t\tdefault:\n")
+	if tag.Default != "" {
+		fmt.Fprintf(w, "\t\t\tif value == \"\" {\n\t\t\t\tvalue = %q\n\t\t\t}\n", tag.Default)
+	}
+	w.Write(setter.Bytes())
+	fmt.Fprintf(w, "\t\t}\n\t}\n\n")
+
+	return nil
+}
+
+// setter emits the conversion of value into target and reports whether the
+// code refers to the name of the variable.
+func (g *generator) setter(w *bytes.Buffer, expr ast.Expr, tag env.Tag, target, path string) (bool, error) {
+	// errors quote the value, which must not show for secrets
+	errExpr := "%s"
+	if tag.Secret {
+		errExpr = fmt.Sprintf("env.SanitizeError(%%s, value, %s, %t)", strings.ReplaceAll(strconv.Quote(tag.Separator), "%", "%%"), tag.NoTrim)
+	}
+	fail := "errs = append(errs, env.ParseError{Env: name, Field: " + strconv.Quote(path) + ", Err: " + errExpr + "})"
+
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		k, ok := kinds[typeName(t.X)]
+		if !ok {
+			return false, fmt.Errorf("unsupported type '*%s'", typeName(t.X))
+		}
+		fmt.Fprintf(w, "\t\t\tp := new(%s)\n", k.name)
+		return g.scalar(w, k, "value", "*p", fail, target+" = p"), nil
+
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return false, fmt.Errorf("unsupported array type")
+		}
+
+		elem := typeName(t.Elt)
+		if elem == "byte" || elem == "uint8" {
+			fmt.Fprintf(w, "\t\t\t%s = []byte(value)\n", target)
+			return false, nil
+		}
+
+		k, ok := kinds[elem]
+		if !ok {
+			return false, fmt.Errorf("unsupported type '[]%s'", elem)
+		}
+
+		sep := tag.Separator
+		if sep == "" {
+			sep = ","
+		}
+
+		g.imports["fmt"] = true
+
+		fmt.Fprintf(w, "\t\t\tif s, err := func() ([]%s, error) {\n", k.name)
+		fmt.Fprintf(w, "\t\t\t\tparts, err := env.SplitList(value, %q, %t)\n", sep, tag.NoTrim)
+		fmt.Fprintf(w, "\t\t\t\tif err != nil {\n\t\t\t\t\treturn nil, err\n\t\t\t\t}\n")
+		fmt.Fprintf(w, "\t\t\t\ts := make([]%s, len(parts))\n", k.name)
+		fmt.Fprintf(w, "\t\t\t\tfor i, part := range parts {\n")
+		g.scalar(w, k, "part", "s[i]", `return nil, fmt.Errorf("error parsing slice element %%d : %%w", i, %s)`, "")
+		fmt.Fprintf(w, "\t\t\t\t}\n\t\t\t\treturn s, nil\n")
+		fmt.Fprintf(w, "\t\t\t}(); err != nil {\n\t\t\t\t%s\n\t\t\t} else {\n\t\t\t\t%s = s\n\t\t\t}\n", fmt.Sprintf(fail, "err"), target)
+		return true, nil
+	}
+
+	k, ok := kinds[typeName(expr)]
+	if !ok {
+		return false, fmt.Errorf("unsupported type '%s'", typeName(expr))
+	}
+	return g.scalar(w, k, "value", target, fail, ""), nil
+}
+
+// scalar emits the conversion of src into dst, running fail with the error
+// or then on success. It reports whether the conversion can fail.
+func (g *generator) scalar(w *bytes.Buffer, k kind, src, dst, fail, then string) bool {
+	var call string
+	switch k.base {
+	case "string":
+		fmt.Fprintf(w, "\t\t\t%s = %s\n", dst, src)
+		if then != "" {
+			fmt.Fprintf(w, "\t\t\t%s\n", then)
+		}
+		return false
+
+	case "bool":
+		call = fmt.Sprintf("strconv.ParseBool(%s)", src)
+	case "int":
+		call = fmt.Sprintf("strconv.ParseInt(%s, 10, %d)", src, k.bits)
+	case "uint":
+		call = fmt.Sprintf("strconv.ParseUint(%s, 10, %d)", src, k.bits)
+	case "float":
+		call = fmt.Sprintf("strconv.ParseFloat(%s, %d)", src, k.bits)
+	case "duration":
+		call = fmt.Sprintf("time.ParseDuration(%s)", src)
+	}
+
+	if k.base == "duration" {
+		g.imports["time"] = true
+	} else {
+		g.imports["strconv"] = true
+	}
+
+	value := "v"
+	if k.base != "bool" && k.base != "duration" && k.name != "int64" && k.name != "uint64" && k.name != "float64" {
+		value = k.name + "(v)"
+	}
+
+	fmt.Fprintf(w, "\t\t\tif v, err := %s; err != nil {\n\t\t\t\t%s\n\t\t\t} else {\n\t\t\t\t%s = %s\n", call, fmt.Sprintf(fail, "err"), dst, value)
+	if then != "" {
+		fmt.Fprintf(w, "\t\t\t\t%s\n", then)
+	}
+	fmt.Fprintf(w, "\t\t\t}\n")
+	return true
+}
+
+func description(tag env.Tag) string {
+	if tag.Description == "" {
+		return ""
+	}
+	return fmt.Sprintf(", Description: %q", tag.Description)
+}
+
+// supported rejects tag options the generated code does not implement rather
+// than silently ignoring them.
+func supported(tag env.Tag) error {
+	var options []string
+	if tag.Expand {
+		options = append(options, "expand")
+	}
+	if tag.File {
+		options = append(options, "file")
+	}
+	if tag.Unset {
+		options = append(options, "unset")
+	}
+	if tag.JSON {
+		options = append(options, "json")
+	}
+	if tag.Encoding != "" {
+		options = append(options, "encoding")
+	}
+	if tag.Layout != "" {
+		options = append(options, "layout")
+	}
+	if tag.Unit != "" {
+		options = append(options, "unit")
+	}
+	if tag.From != "" {
+		options = append(options, "from")
+	}
+	if tag.PathList {
+		options = append(options, "pathlist")
+	}
+	if tag.KeyValueSeparator != "" {
+		options = append(options, "kvSeparator")
+	}
+	if tag.Keep {
+		options = append(options, "keep")
+	}
+	if tag.Encrypted {
+		options = append(options, "encrypted")
+	}
+	if len(tag.Deprecated) > 0 {
+		options = append(options, "deprecated")
+	}
+	for _, c := range tag.RequiredIf {
+		options = append(options, c.String())
+	}
+	if len(tag.OnlyIf) > 0 {
+		options = append(options, "onlyIf")
+	}
+	for _, rule := range tag.Rules {
+		options = append(options, rule.Name)
+	}
+	if strings.Contains(tag.Default, "${") {
+		options = append(options, "default with references")
+	}
+
+	if len(options) > 0 {
+		return fmt.Errorf("unsupported tag options '%s'", strings.Join(options, "', '"))
+	}
+	return nil
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.SelectorExpr:
+		return typeName(t.X) + "." + t.Sel.Name
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
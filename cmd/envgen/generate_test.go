@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		dir   string
+		types []string
+	}{
+		{dir: "basic", types: []string{"Config"}},
+		{dir: "nested", types: []string{"Config"}},
+		{dir: "secret", types: []string{"Config"}},
+		{dir: "validate", types: []string{"Config", "Service"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			dir := filepath.Join("testdata", tt.dir)
+
+			p, err := loadPackage(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := generate(p, tt.types)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join(dir, "config_env.go.golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("error reading golden file : %s, run with -update to create it", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output does not match '%s', run with -update to accept it\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{
+			name: "unsupported option",
+			src:  "type Config struct {\n\tKey string `env:\"KEY,file\"`\n}",
+			err:  "unsupported tag options 'file'",
+		},
+		{
+			name: "unsupported type",
+			src:  "type Config struct {\n\tCh chan int `env:\"CH\"`\n}",
+			err:  "unsupported type",
+		},
+		{
+			name: "missing name",
+			src:  "type Config struct {\n\tHost string `env:\",optional\"`\n}",
+			err:  "missing variable name",
+		},
+		{
+			name: "nested pointer",
+			src:  "type Inner struct {\n\tHost string `env:\"HOST\"`\n}\n\ntype Config struct {\n\tInner *Inner\n}",
+			err:  "pointers to nested structs are not supported",
+		},
+		{
+			name: "indexed",
+			src:  "type Inner struct {\n\tHost string `env:\"HOST\"`\n}\n\ntype Config struct {\n\tInner []Inner `envPrefix:\"INNER_\"`\n}",
+			err:  "field 'Inner' : indexed fields are not supported",
+		},
+		{
+			name: "keyed",
+			src:  "type Inner struct {\n\tHost string `env:\"HOST\"`\n}\n\ntype Config struct {\n\tInner map[string]Inner `envPrefix:\"INNER_\"`\n}",
+			err:  "field 'Inner' : keyed fields are not supported",
+		},
+		{
+			name: "imported struct",
+			src:  "import \"github.com/reverted/env/dsn\"\n\ntype Config struct {\n\tDB dsn.Database\n}",
+			err:  "field 'DB' : nested structs declared in other packages are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte("package config\n\n"+tt.src+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := loadPackage(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = generate(p, []string{"Config"})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error with %q, found %v", tt.err, err)
+			}
+		})
+	}
+}
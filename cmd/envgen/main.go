@@ -0,0 +1,182 @@
+// Command envgen generates reflection free parsers for config structs.
+//
+//	//go:generate go run github.com/reverted/env/cmd/envgen -type Config
+//
+// For a type Config it writes config_env.go with
+//
+//	func ParseConfigFromEnv() (Config, error)
+//	func ParseConfigFromLookup(lookup func(string) (string, bool)) (Config, error)
+//
+// which return the same errors as env.Parse. Fields may be strings, bools,
+// numbers, time.Duration, pointers and slices of those, and nested structs
+// declared in the same package. The optional, required, default, notEmpty,
+// allowEmpty, separator and secret tag options are supported, any other
+// option is reported as an error, as are indexed and keyed fields and
+// untagged fields of types declared in other packages. As with env.Parse,
+// errors for secret fields do not include their values, and once every
+// variable is read the Validate methods of nested structs and then of the
+// type itself are called.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("envgen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of type names")
+	output := flag.String("output", "", "output file name, defaults to <type>_env.go")
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	types := strings.Split(*typeNames, ",")
+
+	src, err := generate(pkg, types)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	name := *output
+	if name == "" {
+		name = strings.ToLower(types[0]) + "_env.go"
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type pkg struct {
+	name  string
+	types map[string]*ast.StructType
+
+	// methods holds the names of the methods declared for each type, on
+	// the type or a pointer to it.
+	methods map[string]map[string]bool
+
+	// imports maps the package names used in the file declaring each struct
+	// to their import paths.
+	imports map[*ast.StructType]map[string]string
+}
+
+// declares reports whether the type named name declares the method.
+func (p *pkg) declares(name, method string) bool {
+	return p.methods[name][method]
+}
+
+// external reports whether expr, used in st, names a type from a package
+// outside the standard library, whose fields env.Parse may well set.
+func (p *pkg) external(st *ast.StructType, expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	path := p.imports[st][typeName(sel.X)]
+	return strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+func loadPackage(dir string) (*pkg, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pkg{
+		types:   map[string]*ast.StructType{},
+		methods: map[string]map[string]bool{},
+		imports: map[*ast.StructType]map[string]string{},
+	}
+
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.name == "" {
+			p.name = file.Name.Name
+		}
+		if file.Name.Name != p.name {
+			continue
+		}
+
+		imports := map[string]string{}
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+			name := path[strings.LastIndex(path, "/")+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = path
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				// Validate only counts with the signature Parse calls
+				if n.Name.Name == "Validate" && (n.Type.Params.NumFields() != 0 || n.Type.Results.NumFields() != 1) {
+					return false
+				}
+				if n.Recv != nil && len(n.Recv.List) == 1 {
+					recv := typeName(n.Recv.List[0].Type)
+					if p.methods[recv] == nil {
+						p.methods[recv] = map[string]bool{}
+					}
+					p.methods[recv][n.Name.Name] = true
+				}
+				return false
+
+			case *ast.TypeSpec:
+				if st, ok := n.Type.(*ast.StructType); ok {
+					p.types[n.Name.Name] = st
+					p.imports[st] = imports
+				}
+				return false
+			}
+			return true
+		})
+	}
+
+	if p.name == "" {
+		return nil, fmt.Errorf("no go files in '%s'", dir)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,17 @@
+package basic
+
+import "time"
+
+type Config struct {
+	Host    string        `env:"HOST|HOSTNAME" desc:"server host"`
+	Port    int           `env:"PORT,default=8080"`
+	Debug   bool          `env:"DEBUG,optional"`
+	Timeout time.Duration `env:"TIMEOUT,default=5s"`
+	Ratio   *float64      `env:"RATIO,optional"`
+	Tags    []string      `env:"TAGS,optional,separator=;"`
+	Ports   []uint16      `env:"PORTS,optional"`
+	Name    string        `env:"NAME,notEmpty"`
+	Label   string        `env:"LABEL,allowEmpty"`
+	Data    []byte        `env:"DATA,optional"`
+	Ignored string        `env:"-"`
+}
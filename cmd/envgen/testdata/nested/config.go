@@ -0,0 +1,16 @@
+package nested
+
+type Database struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT,default=5432"`
+}
+
+type common struct {
+	Region string `env:"REGION,optional"`
+}
+
+type Config struct {
+	common
+	Primary Database `envPrefix:"DB_"`
+	Replica Database `envPrefix:"REPLICA_"`
+}
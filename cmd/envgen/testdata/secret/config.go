@@ -0,0 +1,9 @@
+package secret
+
+type Config struct {
+	Token  string  `env:"TOKEN,secret"`
+	Pin    int     `env:"PIN,secret"`
+	Limit  *uint8  `env:"LIMIT,secret,optional"`
+	Keys   []int64 `env:"KEYS,secret,optional,separator=|"`
+	Public int     `env:"PUBLIC,optional"`
+}
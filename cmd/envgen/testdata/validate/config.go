@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"errors"
+	"time"
+)
+
+type Database struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT,default=5432"`
+}
+
+func (d Database) Validate() error {
+	if d.Port == 0 {
+		return errors.New("missing port")
+	}
+	return nil
+}
+
+type common struct {
+	Region string `env:"REGION,optional"`
+}
+
+func (c *common) Validate() error {
+	if c.Region == "" {
+		c.Region = "local"
+	}
+	return nil
+}
+
+// Level is converted as a whole, like env.Parse does for setters.
+type Level struct {
+	name string
+}
+
+func (l *Level) SetEnv(value string) error {
+	l.name = value
+	return nil
+}
+
+type Config struct {
+	common
+	Primary Database `envPrefix:"DB_"`
+	Replica Database `envPrefix:"REPLICA_"`
+	Level   Level
+	Started time.Time
+}
+
+func (c *Config) Validate() error {
+	if c.Primary.Host == c.Replica.Host {
+		return errors.New("replica must differ from primary")
+	}
+	return nil
+}
+
+// Service has the Validate method of common promoted.
+type Service struct {
+	common
+	Name string `env:"NAME"`
+}
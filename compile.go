@@ -0,0 +1,234 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Schema is a checked configuration type, see Compile.
+type Schema[T any] struct {
+	opts Options
+}
+
+// plan holds what parsing T works out for every field regardless of the
+// values found, so a Schema does it once.
+type plan struct {
+	// tags holds the resolved tags of the fields outside of indexed and
+	// keyed fields, by path.
+	tags map[string]Tag
+
+	// kinds holds how each field of every struct type is parsed.
+	kinds map[reflect.Type][]int
+}
+
+func newPlan(t reflect.Type, o Options) (*plan, error) {
+	p := &plan{tags: map[string]Tag{}, kinds: map[reflect.Type][]int{}}
+	p.classify(t, o)
+
+	err := walkAll(reflect.New(t), o, func(f fieldInfo) error {
+		if !f.pattern {
+			p.tags[f.path] = f.tag
+		}
+		return nil
+	})
+	return p, err
+}
+
+func (p *plan) classify(t reflect.Type, o Options) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if _, ok := p.kinds[t]; ok || t.Kind() != reflect.Struct {
+		return
+	}
+
+	fields := fieldsOf(t, o.tagStyle())
+	kinds := make([]int, len(fields))
+	p.kinds[t] = kinds
+
+	for i, f := range fields {
+		kinds[i] = o.classify(f)
+		switch kinds[i] {
+		case fieldNested:
+			p.classify(f.Type, o)
+		case fieldIndexed, fieldKeyed:
+			p.classify(f.Type.Elem(), o)
+		}
+	}
+}
+
+// kind returns how field i of t is parsed.
+func (p *plan) kind(t reflect.Type, i int, f field, o Options) int {
+	if p != nil {
+		if kinds, ok := p.kinds[t]; ok {
+			return kinds[i]
+		}
+	}
+	return o.classify(f)
+}
+
+// tag returns the resolved tag of the field at path, if planned.
+func (p *plan) tag(path string) (Tag, bool) {
+	if p == nil {
+		return Tag{}, false
+	}
+	tag, ok := p.tags[path]
+	return tag, ok
+}
+
+// Compile checks every tag of T for invalid and unknown options, that every
+// field type can be parsed and that named validators are registered, so
+// mistakes show up at startup rather than on first parse. Fields in the
+// elements of indexed and keyed fields are checked too. The schema can then
+// be parsed repeatedly, reusing the variable names and field kinds worked
+// out when compiling.
+func Compile[T any](opts ...Option) (*Schema[T], error) {
+	o := newOptions(opts)
+
+	var obj T
+	v := reflect.ValueOf(&obj)
+	if !isStruct(v.Type().Elem()) {
+		return nil, fmt.Errorf("cannot compile '%T' : expected a struct", obj)
+	}
+
+	var errs []error
+	err := walkAll(v, o, func(f fieldInfo) error {
+		if !o.supports(f.field.Type, f.tag) {
+			errs = append(errs, UnsupportedError{Env: f.tag.Env, Field: f.path, Type: f.field.Type})
+		}
+
+		for _, option := range f.tag.Unknown {
+			errs = append(errs, fmt.Errorf("unknown option '%s' in tag of field '%s'", option, f.path))
+		}
+
+		for _, rule := range f.tag.Rules {
+			if rule.Name != "validate" {
+				continue
+			}
+			for _, name := range strings.Split(rule.Arg, "|") {
+				if _, ok := o.validator(name); !ok {
+					errs = append(errs, fmt.Errorf("unknown validator '%s' for field '%s'", name, f.path))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := o.conflicts(v.Type().Elem()); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	p, err := newPlan(v.Type().Elem(), o)
+	if err != nil {
+		return nil, err
+	}
+	o.plan = p
+
+	return &Schema[T]{opts: o}, nil
+}
+
+// Parse resolves the schema from l, or from the sources configured when
+// compiling if l is nil.
+func (s *Schema[T]) Parse(l Lookuper) (T, error) {
+	return s.ParseContext(context.Background(), l)
+}
+
+func (s *Schema[T]) ParseContext(ctx context.Context, l Lookuper) (T, error) {
+	opts := s.opts
+	if l != nil {
+		opts.Lookuper = l
+	}
+
+	var obj T
+	err := parse(ctx, &obj, opts)
+	return obj, err
+}
+
+// Precedence lists the sources a field is resolved from with the options the
+// schema was compiled with, highest first. Each of a field's names, its name
+// before its aliases and deprecated names, is tried in every source before
+// the next name, then the existing value with keep and finally the default.
+func (s *Schema[T]) Precedence() []string {
+	o := s.opts
+
+	var sources []string
+	if o.Flags != nil {
+		sources = append(sources, SourceFlag)
+	}
+	if len(o.Vars) > 0 {
+		sources = append(sources, SourceVars)
+	}
+
+	switch {
+	case o.Lookuper != nil:
+		sources = append(sources, sourceNames(o.Lookuper)...)
+	case !o.Hermetic:
+		sources = append(sources, SourceEnv)
+	}
+
+	if len(o.Dotenv) > 0 {
+		sources = append(sources, SourceDotenv)
+	}
+	if o.FileFallback {
+		sources = append(sources, SourceFile)
+	}
+
+	return append(sources, SourceExisting, SourceDefault)
+}
+
+func sourceNames(l Lookuper) []string {
+	s, ok := l.(sources)
+	if !ok {
+		return []string{sourceName(l)}
+	}
+
+	var names []string
+	for _, l := range s {
+		names = append(names, sourceNames(l)...)
+	}
+	return names
+}
+
+// supports reports whether setField can handle a field of type t.
+func (o Options) supports(t reflect.Type, tag Tag) bool {
+	if tag.JSON {
+		return true
+	}
+
+	if _, ok := o.parser(t); ok {
+		return true
+	}
+
+	if t == durationType || implementsSetter(t) || t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	if isByteSlice(t) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return t.Elem() != t && o.supports(t.Elem(), tag)
+
+	case reflect.Map:
+		return o.supports(t.Key(), tag) && o.supports(t.Elem(), tag)
+
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
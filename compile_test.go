@@ -0,0 +1,156 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type compileValid struct {
+	Host    string           `env:"HOST"`
+	Port    int              `env:"PORT,default=80,min=1"`
+	Servers []describeServer `envPrefix:"SERVERS_"`
+}
+
+type compileUnknown struct {
+	Host string `env:"HOST,optinal"`
+	Port int    `env:"PORT,secret,bogus=1"`
+}
+
+type compileElement struct {
+	Ch chan int `env:"CH"`
+}
+
+type compileIndexed struct {
+	Elements []compileElement `envPrefix:"E_"`
+}
+
+type compileKeyed struct {
+	Elements map[string]compileUnknown `envPrefix:"E_"`
+}
+
+type compileOnlyIf struct {
+	Cert string `env:"CERT,onlyIf=TLS"`
+}
+
+type compileValidator struct {
+	Name string `env:"NAME,validate=missing"`
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		compile func() error
+		errs    []string
+	}{
+		{
+			name:    "valid",
+			compile: func() error { _, err := Compile[compileValid](); return err },
+		},
+		{
+			name:    "unknown options",
+			compile: func() error { _, err := Compile[compileUnknown](); return err },
+			errs: []string{
+				"unknown option 'optinal' in tag of field 'Host'",
+				"unknown option 'bogus' in tag of field 'Port'",
+			},
+		},
+		{
+			name:    "indexed element type",
+			compile: func() error { _, err := Compile[compileIndexed](); return err },
+			errs:    []string{"Elements[<n>].Ch"},
+		},
+		{
+			name:    "keyed element type",
+			compile: func() error { _, err := Compile[compileKeyed](); return err },
+			errs: []string{
+				"unknown option 'optinal' in tag of field 'Elements[<key>].Host'",
+				"unknown option 'bogus' in tag of field 'Elements[<key>].Port'",
+			},
+		},
+		{
+			name:    "onlyIf on a value",
+			compile: func() error { _, err := Compile[compileOnlyIf](); return err },
+			errs:    []string{"error parsing tag of field 'Cert' : onlyIf is only supported on nested structs"},
+		},
+		{
+			name:    "unknown validator",
+			compile: func() error { _, err := Compile[compileValidator](); return err },
+			errs:    []string{"unknown validator 'missing' for field 'Name'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.compile()
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected errors %q", tt.errs)
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaParse(t *testing.T) {
+	s, err := Compile[compileValid](WithHermetic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := s.Parse(Map{"HOST": "localhost", "SERVERS_0_HOST": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "localhost" || cfg.Port != 80 || len(cfg.Servers) != 1 || cfg.Servers[0].Host != "a" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestSchemaPlan(t *testing.T) {
+	type inner struct {
+		Name string `env:"NAME|ALIAS"`
+	}
+	type config struct {
+		Host    string
+		Inner   *inner           `envPrefix:"INNER_"`
+		Servers []describeServer `envPrefix:"SERVERS_"`
+	}
+
+	s, err := Compile[config](WithPrefix("APP_"), WithAutoNames(SnakeUpper), WithHermetic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := s.opts.plan
+	if tag, ok := p.tag("Host"); !ok || tag.Env != "APP_HOST" {
+		t.Errorf("expected Host to be read from APP_HOST, found %+v", tag)
+	}
+	if tag, ok := p.tag("Inner.Name"); !ok || tag.Env != "APP_INNER_NAME" || !reflect.DeepEqual(tag.Aliases, []string{"APP_INNER_ALIAS"}) {
+		t.Errorf("expected Inner.Name to be read from APP_INNER_NAME or APP_INNER_ALIAS, found %+v", tag)
+	}
+	if len(p.tags) != 2 {
+		t.Errorf("expected the elements of Servers to be left out, found %+v", p.tags)
+	}
+	if kinds := p.kinds[reflect.TypeOf(config{})]; !reflect.DeepEqual(kinds, []int{fieldValue, fieldNested, fieldIndexed}) {
+		t.Errorf("unexpected field kinds %v", kinds)
+	}
+
+	cfg, err := s.Parse(Map{"APP_HOST": "h", "APP_INNER_ALIAS": "n", "APP_SERVERS_0_HOST": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "h" || cfg.Inner == nil || cfg.Inner.Name != "n" || len(cfg.Servers) != 1 || cfg.Servers[0].Host != "a" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
@@ -0,0 +1,110 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Condition makes a field required depending on another variable. With a
+// Value, from requiredIf=NAME=VALUE, the variable must equal it, otherwise,
+// from requiredWith=NAME, it must be set to any non-empty value.
+type Condition struct {
+	Env   string
+	Value string
+	With  bool
+}
+
+// newCondition parses the argument of a requiredIf, requiredWith or onlyIf
+// option. onlyIf=NAME is short for onlyIf=NAME=true.
+func newCondition(option, arg string) (Condition, error) {
+	name, value, hasValue := strings.Cut(arg, "=")
+	if name == "" {
+		return Condition{}, fmt.Errorf("expected '%s=NAME'", option)
+	}
+
+	switch {
+	case option == "requiredWith":
+		if hasValue {
+			return Condition{}, fmt.Errorf("expected '%s=NAME'", option)
+		}
+		return Condition{Env: name, With: true}, nil
+
+	case option == "onlyIf" && !hasValue:
+		return Condition{Env: name, Value: "true"}, nil
+
+	case !hasValue:
+		return Condition{}, fmt.Errorf("expected '%s=NAME=value'", option)
+	}
+
+	return Condition{Env: name, Value: value}, nil
+}
+
+// parseCondition parses a condition in the form returned by String, or an
+// onlyIf condition as listed by VarSpec.OnlyIf.
+func parseCondition(s string) (Condition, error) {
+	option, arg, _ := strings.Cut(s, "=")
+	if option != "requiredIf" && option != "requiredWith" && option != "onlyIf" {
+		return Condition{}, fmt.Errorf("invalid condition '%s'", s)
+	}
+	return newCondition(option, arg)
+}
+
+func (c Condition) String() string {
+	if c.With {
+		return "requiredWith=" + c.Env
+	}
+	return "requiredIf=" + c.Env + "=" + c.Value
+}
+
+// holds reports whether the condition is met by value. Booleans match in any
+// of the forms strconv.ParseBool accepts.
+func (c Condition) holds(value string) bool {
+	if c.With {
+		return value != ""
+	}
+
+	if value == c.Value {
+		return true
+	}
+
+	want, err := strconv.ParseBool(c.Value)
+	if err != nil {
+		return false
+	}
+	got, err := strconv.ParseBool(value)
+	return err == nil && got == want
+}
+
+func (d *decoder) holds(conditions []Condition, prefix string) (bool, error) {
+	for _, c := range conditions {
+		value, err := d.reference(c.Env, prefix)
+		if err != nil {
+			return false, err
+		}
+		if !c.holds(value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// required reports whether a field must be set. Fields with conditions are
+// only required when one of them holds. Variables of fields parsed earlier are
+// seen with their defaults applied, others are looked up directly.
+func (d *decoder) required(tag Tag, prefix string) (bool, error) {
+	if len(tag.RequiredIf) == 0 {
+		return !d.opts.optional(tag), nil
+	}
+
+	for _, c := range tag.RequiredIf {
+		value, err := d.reference(c.Env, prefix)
+		if err != nil {
+			return false, err
+		}
+		if c.holds(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
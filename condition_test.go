@@ -0,0 +1,107 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// conditionLookuper records the keys looked up and fails for those in fail.
+type conditionLookuper struct {
+	vars   Map
+	fail   map[string]bool
+	looked []string
+}
+
+func (l *conditionLookuper) Lookup(key string) (string, bool) {
+	value, ok, _ := l.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (l *conditionLookuper) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	l.looked = append(l.looked, key)
+	if l.fail[key] {
+		return "", false, errors.New("lookup failed")
+	}
+	value, ok := l.vars[key]
+	return value, ok, nil
+}
+
+func TestRequiredConditions(t *testing.T) {
+	type config struct {
+		Cert string `env:"CERT,requiredIf=TLS=true,requiredWith=KEY"`
+	}
+
+	tests := []struct {
+		name   string
+		vars   Map
+		fail   []string
+		looked []string
+		err    interface{}
+	}{
+		{
+			name:   "no condition holds",
+			vars:   Map{"TLS": "false"},
+			looked: []string{"CERT", "TLS", "KEY"},
+		},
+		{
+			name:   "requiredIf holds",
+			vars:   Map{"TLS": "1"},
+			looked: []string{"CERT", "TLS"},
+			err:    &MissingError{},
+		},
+		{
+			name:   "requiredWith holds",
+			vars:   Map{"KEY": "k"},
+			looked: []string{"CERT", "TLS", "KEY"},
+			err:    &MissingError{},
+		},
+		{
+			name:   "failed condition lookup",
+			fail:   []string{"TLS"},
+			looked: []string{"CERT", "TLS"},
+			err:    &LookupError{},
+		},
+		{
+			name:   "set value skips the conditions",
+			vars:   Map{"CERT": "c"},
+			fail:   []string{"TLS", "KEY"},
+			looked: []string{"CERT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &conditionLookuper{vars: tt.vars, fail: map[string]bool{}}
+			for _, key := range tt.fail {
+				l.fail[key] = true
+			}
+
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(l), WithHermetic())
+			switch {
+			case tt.err == nil && err != nil:
+				t.Fatal(err)
+			case tt.err != nil && !errors.As(err, tt.err):
+				t.Fatalf("expected %T, found %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(l.looked, tt.looked) {
+				t.Errorf("expected lookups %q, found %q", tt.looked, l.looked)
+			}
+		})
+	}
+}
+
+func TestOnlyIfOnValue(t *testing.T) {
+	type config struct {
+		Cert string `env:"CERT,onlyIf=TLS"`
+	}
+
+	var cfg config
+	err := ParseWithOptions(&cfg, WithLookuper(Map{"TLS": "true", "CERT": "c"}), WithHermetic())
+	if err == nil || !strings.Contains(err.Error(), "onlyIf is only supported on nested structs") {
+		t.Fatalf("expected onlyIf error, found %v", err)
+	}
+}
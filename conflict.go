@@ -0,0 +1,83 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type ConflictError struct {
+	Env   string
+	Field string
+	Other string
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("env '%s' of field '%s' is also used by field '%s'", e.Env, e.Field, e.Other)
+}
+
+func (e ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+type conflictKey struct {
+	t      reflect.Type
+	prefix string
+	style  tagStyle
+}
+
+// conflictCache holds the result of conflicts for every type and prefix
+// checked so far. Results with Options.AutoNames, LegacyNames or Rename are
+// not cached, since the names depend on the functions, nor are those with
+// Options.Parsers, which decide the structs that are descended into. For the
+// same reason RegisterParser clears the cache.
+var (
+	conflictMu    sync.RWMutex
+	conflictCache = map[conflictKey]error{}
+)
+
+// conflicts reports variables read by more than one field, which would
+// otherwise leave them all set from the same variable without any hint.
+// Names inside slices and maps of structs are distinct per element and are
+// not checked. Tag errors are left for parsing to report.
+func (o Options) conflicts(t reflect.Type) error {
+	key := conflictKey{t: t, prefix: o.Prefix, style: o.tagStyle()}
+	cacheable := o.AutoNames == nil && o.LegacyNames == nil && o.Rename == nil && len(o.Parsers) == 0
+	if cacheable {
+		conflictMu.RLock()
+		err, ok := conflictCache[key]
+		conflictMu.RUnlock()
+		if ok {
+			return err
+		}
+	}
+
+	names := map[string]string{}
+
+	var errs []error
+	_ = walkAll(reflect.New(t), o, func(f fieldInfo) error {
+		for _, name := range f.tag.Names() {
+			if other, ok := names[name]; ok {
+				errs = append(errs, ConflictError{Env: name, Field: f.path, Other: other})
+				continue
+			}
+			names[name] = f.path
+		}
+		return nil
+	})
+
+	err := errors.Join(errs...)
+	if cacheable {
+		conflictMu.Lock()
+		conflictCache[key] = err
+		conflictMu.Unlock()
+	}
+	return err
+}
+
+func clearConflicts() {
+	conflictMu.Lock()
+	conflictCache = map[conflictKey]error{}
+	conflictMu.Unlock()
+}
@@ -0,0 +1,197 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type conflictShared struct {
+	Host    string `env:"HOST"`
+	Address string `env:"ADDRESS|HOST"`
+}
+
+type conflictNested struct {
+	DB struct {
+		Host string `env:"HOST"`
+	} `envPrefix:"DB_"`
+	Host string `env:"DB_HOST,deprecated=HOST"`
+}
+
+type conflictElement struct {
+	Host string `env:"HOST"`
+}
+
+type conflictIndexed struct {
+	Host    string            `env:"HOST"`
+	Servers []conflictElement `envPrefix:"SERVERS_"`
+}
+
+func TestConflictingTags(t *testing.T) {
+	tests := []struct {
+		tag string
+		err string
+	}{
+		{tag: `env:"A,optional,default=x"`, err: "conflicting options 'optional' and 'default'"},
+		{tag: `env:"A,required,default=x"`, err: "conflicting options 'required' and 'default'"},
+		{tag: `env:"A,notEmpty,allowEmpty"`, err: "conflicting options 'notEmpty' and 'allowEmpty'"},
+		{tag: `env:"A,optional,notEmpty"`},
+		{tag: `env:"A,default=x,notEmpty"`},
+	}
+
+	for _, tt := range tests {
+		_, _, err := ParseTag(reflect.StructTag(tt.tag))
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.tag, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected %q, found %v", tt.tag, tt.err, err)
+		}
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func() error
+		errs  []ConflictError
+	}{
+		{
+			name: "alias",
+			parse: func() error {
+				var cfg conflictShared
+				return ParseWithOptions(&cfg, WithHermetic())
+			},
+			errs: []ConflictError{{Env: "HOST", Field: "Address", Other: "Host"}},
+		},
+		{
+			name: "prefixed and deprecated",
+			parse: func() error {
+				var cfg conflictNested
+				return ParseWithOptions(&cfg, WithHermetic())
+			},
+			errs: []ConflictError{{Env: "DB_HOST", Field: "Host", Other: "DB.Host"}},
+		},
+		{
+			name: "prefix option",
+			parse: func() error {
+				var cfg conflictNested
+				return ParseWithOptions(&cfg, WithHermetic(), WithPrefix("APP_"))
+			},
+			errs: []ConflictError{{Env: "APP_DB_HOST", Field: "Host", Other: "DB.Host"}},
+		},
+		{
+			name: "compile",
+			parse: func() error {
+				_, err := Compile[conflictShared]()
+				return err
+			},
+			errs: []ConflictError{{Env: "HOST", Field: "Address", Other: "Host"}},
+		},
+		{
+			name: "slice elements",
+			parse: func() error {
+				var cfg conflictIndexed
+				return ParseWithOptions(&cfg, WithLookuper(Map{"HOST": "a", "SERVERS_0_HOST": "b"}))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.parse()
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("expected ErrConflict, found %v", err)
+			}
+			for _, want := range tt.errs {
+				if !strings.Contains(err.Error(), want.Error()) {
+					t.Errorf("expected %q in %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConflictsWithParsers(t *testing.T) {
+	type config struct {
+		Host  string `env:"HOST"`
+		Value conflictElement
+	}
+	typ := reflect.TypeOf(config{})
+	elemType := reflect.TypeOf(conflictElement{})
+	parse := func(value string) (interface{}, error) { return conflictElement{Host: value}, nil }
+
+	if err := (Options{}).conflicts(typ); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict, found %v", err)
+	}
+
+	// a parsed struct is not descended into
+	o := newOptions([]Option{WithParsers(map[reflect.Type]ParserFunc{elemType: parse})})
+	if err := o.conflicts(typ); err != nil {
+		t.Errorf("expected no conflict with Parsers, found %v", err)
+	}
+
+	RegisterParser(elemType, parse)
+	t.Cleanup(func() {
+		parsersMu.Lock()
+		delete(parsers, elemType)
+		parsersMu.Unlock()
+		clearConflicts()
+	})
+
+	if err := (Options{}).conflicts(typ); err != nil {
+		t.Errorf("expected no conflict once registered, found %v", err)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{SourceEnv, SourceExisting, SourceDefault},
+		},
+		{
+			name: "hermetic",
+			opts: []Option{WithHermetic()},
+			want: []string{SourceExisting, SourceDefault},
+		},
+		{
+			name: "all",
+			opts: []Option{
+				WithFlags(Map{}),
+				WithVars(map[string]string{"A": "a"}),
+				WithLookuper(Sources(Named("first", Map{}), Map{})),
+				WithDotenv(".env"),
+				WithFileFallback(),
+			},
+			want: []string{SourceFlag, SourceVars, "first", SourceLookuper, SourceDotenv, SourceFile, SourceExisting, SourceDefault},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile[compileValid](tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Precedence(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, found %q", tt.want, got)
+			}
+		})
+	}
+}
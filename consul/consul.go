@@ -0,0 +1,204 @@
+// Package consul resolves variables from a Consul KV prefix using Consul's
+// HTTP API.
+//
+// Every key under the prefix becomes a variable, so myapp/db/host under the
+// prefix myapp/ is read as DB_HOST. Values are cached for a TTL, which
+// env.Holder's Watch can be combined with to pick up changes at runtime.
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type options struct {
+	token      string
+	datacenter string
+	client     *http.Client
+	ttl        time.Duration
+	mapName    func(path string) string
+}
+
+type Option func(*options)
+
+// WithToken sets the ACL token sent with every request.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+func WithDatacenter(datacenter string) Option {
+	return func(o *options) {
+		o.datacenter = datacenter
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithTTL sets how long the keys are cached, defaults to one minute.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithNameMapper maps a key path, relative to the prefix, to a variable name.
+// The default upper cases the path and replaces '/', '-' and '.' with '_'.
+func WithNameMapper(fn func(path string) string) Option {
+	return func(o *options) {
+		o.mapName = fn
+	}
+}
+
+// KeyName is the default name mapper.
+func KeyName(path string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(path))
+}
+
+type Source struct {
+	addr   string
+	prefix string
+	opts   options
+
+	mu      sync.Mutex
+	data    map[string]string
+	dataExp time.Time
+}
+
+func New(addr, prefix string, opts ...Option) *Source {
+	o := options{
+		client:  http.DefaultClient,
+		ttl:     time.Minute,
+		mapName: KeyName,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	prefix = strings.TrimPrefix(prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return &Source{
+		addr:   strings.TrimSuffix(addr, "/"),
+		prefix: prefix,
+		opts:   o,
+	}
+}
+
+func (s *Source) Lookup(key string) (string, bool) {
+	value, ok, _ := s.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (s *Source) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	data, err := s.keys(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, ok := data[key]
+	return value, ok, nil
+}
+
+// Keys lists the variable names under the prefix, errors are treated as an
+// empty prefix.
+func (s *Source) Keys() []string {
+	data, _ := s.keys(context.Background())
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *Source) SourceName() string {
+	return "consul"
+}
+
+func (s *Source) keys(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.data != nil && now.Before(s.dataExp) {
+		return s.data, nil
+	}
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.data, s.dataExp = data, now.Add(s.opts.ttl)
+	return data, nil
+}
+
+func (s *Source) read(ctx context.Context) (map[string]string, error) {
+	query := url.Values{"recurse": {"true"}}
+	if s.opts.datacenter != "" {
+		query.Set("dc", s.opts.datacenter)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.addr+"/v1/kv/"+s.prefix+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.opts.token != "" {
+		req.Header.Set("X-Consul-Token", s.opts.token)
+	}
+
+	resp, err := s.opts.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading prefix '%s' : %w", s.prefix, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading prefix '%s' : %w", s.prefix, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return map[string]string{}, nil
+	default:
+		return nil, fmt.Errorf("error reading prefix '%s' : unexpected status %d : %s", s.prefix, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// values are base64 encoded, which []byte decodes
+	var pairs []struct {
+		Key   string `json:"Key"`
+		Value []byte `json:"Value"`
+	}
+	if err := json.Unmarshal(body, &pairs); err != nil {
+		return nil, fmt.Errorf("error reading prefix '%s' : %w", s.prefix, err)
+	}
+
+	data := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		path := strings.TrimPrefix(pair.Key, s.prefix)
+		if path == "" || strings.HasSuffix(path, "/") {
+			continue
+		}
+		data[s.opts.mapName(path)] = string(pair.Value)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,152 @@
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type server struct {
+	*httptest.Server
+	reads int32
+}
+
+func newServer(t *testing.T) *server {
+	pairs := `[
+		{"Key": "myapp/", "Value": null},
+		{"Key": "myapp/db/host", "Value": "` + base64.StdEncoding.EncodeToString([]byte("db")) + `"},
+		{"Key": "myapp/log-level", "Value": "` + base64.StdEncoding.EncodeToString([]byte("debug")) + `"},
+		{"Key": "myapp/tls.cert", "Value": "` + base64.StdEncoding.EncodeToString([]byte("line 1\nline 2")) + `"},
+		{"Key": "myapp/empty/", "Value": null}
+	]`
+
+	s := &server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.reads, 1)
+
+		switch {
+		case r.URL.Query().Get("recurse") != "true":
+			http.Error(w, "expected recurse", http.StatusBadRequest)
+
+		case r.Header.Get("X-Consul-Token") == "bad":
+			http.Error(w, "ACL not found", http.StatusForbidden)
+
+		case r.URL.Path == "/v1/kv/myapp/" && r.URL.Query().Get("dc") == "":
+			w.Write([]byte(pairs))
+
+		case r.URL.Path == "/v1/kv/myapp/" && r.URL.Query().Get("dc") == "eu":
+			w.Write([]byte(`[{"Key": "myapp/region", "Value": "` + base64.StdEncoding.EncodeToString([]byte("eu")) + `"}]`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		opts   []Option
+		keys   []string
+		values map[string]string
+		err    bool
+	}{
+		{
+			name:   "prefix",
+			prefix: "/myapp",
+			keys:   []string{"DB_HOST", "LOG_LEVEL", "TLS_CERT"},
+			values: map[string]string{"DB_HOST": "db", "LOG_LEVEL": "debug", "TLS_CERT": "line 1\nline 2", "EMPTY": ""},
+		},
+		{
+			name:   "name mapper",
+			prefix: "myapp/",
+			opts:   []Option{WithNameMapper(strings.ToUpper)},
+			keys:   []string{"DB/HOST", "LOG-LEVEL", "TLS.CERT"},
+			values: map[string]string{"DB/HOST": "db"},
+		},
+		{
+			name:   "datacenter",
+			prefix: "myapp",
+			opts:   []Option{WithDatacenter("eu"), WithToken("token")},
+			keys:   []string{"REGION"},
+			values: map[string]string{"REGION": "eu"},
+		},
+		{
+			name:   "missing prefix",
+			prefix: "other",
+		},
+		{
+			name:   "denied",
+			prefix: "myapp",
+			opts:   []Option{WithToken("bad")},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t)
+			s := New(srv.URL+"/", tt.prefix, tt.opts...)
+
+			keys := s.Keys()
+			sort.Strings(keys)
+			if len(keys) != len(tt.keys) || len(keys) > 0 && !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("expected keys %v, found %v", tt.keys, keys)
+			}
+
+			for key, want := range tt.values {
+				value, ok, err := s.LookupContext(context.Background(), key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if value != want || ok != (want != "") {
+					t.Errorf("expected '%s' for %s, found '%s', %v", want, key, value, ok)
+				}
+			}
+
+			if _, _, err := s.LookupContext(context.Background(), "ANY"); (err != nil) != tt.err {
+				t.Errorf("expected error %v, found %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestSourceCaching(t *testing.T) {
+	srv := newServer(t)
+	s := New(srv.URL, "myapp")
+
+	for _, key := range []string{"DB_HOST", "LOG_LEVEL", "MISSING"} {
+		if _, _, err := s.LookupContext(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Keys()
+
+	if reads := atomic.LoadInt32(&srv.reads); reads != 1 {
+		t.Errorf("expected 1 read, found %d", reads)
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"host", "HOST"},
+		{"db/host", "DB_HOST"},
+		{"log-level", "LOG_LEVEL"},
+		{"tls.cert", "TLS_CERT"},
+	}
+
+	for _, tt := range tests {
+		if got := KeyName(tt.path); got != tt.want {
+			t.Errorf("expected '%s' for '%s', found '%s'", tt.want, tt.path, got)
+		}
+	}
+}
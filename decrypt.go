@@ -0,0 +1,33 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EncryptedPrefix marks a value as ciphertext to pass to Options.Decryptor,
+// for fields without the encrypted tag option.
+const EncryptedPrefix = "enc:"
+
+// decrypt returns the plaintext of an encrypted value, reporting whether the
+// value was decrypted.
+func (o Options) decrypt(value string, tag Tag) (string, bool, error) {
+	if value == "" {
+		return value, false, nil
+	}
+
+	ciphertext, prefixed := strings.CutPrefix(value, EncryptedPrefix)
+	if !tag.Encrypted && (!prefixed || o.Decryptor == nil) {
+		return value, false, nil
+	}
+
+	if o.Decryptor == nil {
+		return "", false, fmt.Errorf("encrypted value without a decryptor")
+	}
+
+	plaintext, err := o.Decryptor(ciphertext)
+	if err != nil {
+		return "", false, fmt.Errorf("error decrypting value : %w", err)
+	}
+	return plaintext, true, nil
+}
@@ -0,0 +1,128 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func reverseDecryptor(ciphertext string) (string, error) {
+	if ciphertext == "bad" {
+		return "", errors.New("bad ciphertext")
+	}
+
+	r := []rune(ciphertext)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r), nil
+}
+
+func TestDecrypt(t *testing.T) {
+	tests := []struct {
+		name      string
+		tag       reflect.StructTag
+		vars      Map
+		decryptor bool
+		want      string
+		err       bool
+	}{
+		{name: "encrypted option", tag: `env:"V,encrypted"`, vars: Map{"V": "terces"}, decryptor: true, want: "secret"},
+		{name: "prefix", tag: `env:"V"`, vars: Map{"V": "enc:terces"}, decryptor: true, want: "secret"},
+		{name: "plain value", tag: `env:"V"`, vars: Map{"V": "secret"}, decryptor: true, want: "secret"},
+		{name: "prefix without decryptor", tag: `env:"V"`, vars: Map{"V": "enc:terces"}, want: "enc:terces"},
+		{name: "encrypted option without decryptor", tag: `env:"V,encrypted"`, vars: Map{"V": "terces"}, err: true},
+		{name: "encrypted default", tag: `env:"V,encrypted,default=terces"`, decryptor: true, want: "secret"},
+		{name: "empty value", tag: `env:"V,encrypted,optional"`, decryptor: true},
+		{name: "decryptor error", tag: `env:"V,encrypted"`, vars: Map{"V": "bad"}, decryptor: true, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(""), Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			opts := []Option{WithLookuper(tt.vars), WithHermetic()}
+			if tt.decryptor {
+				opts = append(opts, WithDecryptor(reverseDecryptor))
+			}
+
+			err := ParseWithOptions(v.Interface(), opts...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err != nil {
+				var parseErr ParseError
+				if !errors.As(err, &parseErr) {
+					t.Errorf("expected a ParseError, found %T", err)
+				}
+				return
+			}
+			if got := v.Elem().Field(0).String(); got != tt.want {
+				t.Errorf("expected '%s', found '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDecryptSecret(t *testing.T) {
+	var config struct {
+		Port int `env:"PORT"`
+	}
+
+	err := ParseWithOptions(&config, WithLookuper(Map{"PORT": "enc:nekot-ym"}), WithHermetic(), WithDecryptor(reverseDecryptor))
+
+	var parseErr ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a ParseError, found %v", err)
+	}
+	if msg := err.Error(); strings.Contains(msg, "my-token") || strings.Contains(msg, "nekot-ym") {
+		t.Errorf("expected the decrypted value to be treated as a secret, found '%s'", msg)
+	}
+}
+
+func TestDecryptedValuesHidden(t *testing.T) {
+	type config struct {
+		Key   string `env:"KEY,encrypted"`
+		Token string `env:"TOKEN"`
+		Host  string `env:"HOST"`
+	}
+
+	cfg := config{Key: "HUNTER2", Host: "h"}
+	if dumped := DumpMap(&cfg); dumped["KEY"] != Redacted || dumped["HOST"] != "h" {
+		t.Errorf("expected KEY to be redacted, found %v", dumped)
+	}
+
+	specs, err := Describe(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !specs[0].Secret || specs[1].Secret {
+		t.Errorf("expected only KEY to be secret, found %+v", specs)
+	}
+
+	vars := &watchVars{vars: map[string]string{"KEY": "a", "TOKEN": "enc:b", "HOST": "h"}}
+	h, err := NewHolder[config](WithLookuper(vars), WithHermetic(), WithDecryptor(reverseDecryptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars.set("KEY", "c")
+	vars.set("TOKEN", "enc:d")
+	vars.set("HOST", "g")
+	diff, err := h.reload(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Diff{
+		{Field: "Key", Env: "KEY", Old: Redacted, New: Redacted},
+		{Field: "Token", Env: "TOKEN", Old: Redacted, New: Redacted},
+		{Field: "Host", Env: "HOST", Old: "h", New: "g"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("expected %v, found %v", want, diff)
+	}
+}
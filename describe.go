@@ -0,0 +1,85 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type VarSpec struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Deprecated  []string `json:"deprecated,omitempty"`
+	Field       string   `json:"field"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	RequiredIf  []string `json:"requiredIf,omitempty"`
+	OnlyIf      []string `json:"onlyIf,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Secret      bool     `json:"secret,omitempty"`
+	Validators  []string `json:"validators,omitempty"`
+
+	// Pattern marks the variables of indexed and keyed fields, whose names
+	// hold <n> or <key> in place of the index or map key, as in
+	// APP_SERVERS_<n>_HOST.
+	Pattern bool `json:"pattern,omitempty"`
+
+	// Tag is the raw env tag, which Verify parses again.
+	Tag string `json:"tag"`
+}
+
+// Describe lists every variable obj consumes, including those inside nil
+// struct pointers. Indexed and keyed fields are described once by their
+// element type, see VarSpec.Pattern. obj may be a struct or a pointer to one
+// and is not modified.
+func Describe(obj interface{}, opts ...Option) ([]VarSpec, error) {
+	o := newOptions(opts)
+
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() || !isStruct(v.Type()) {
+		return nil, fmt.Errorf("cannot describe '%T' : expected a struct", obj)
+	}
+
+	var specs []VarSpec
+
+	err := walkAll(v, o, func(f fieldInfo) error {
+		spec := VarSpec{
+			Name:        f.tag.Env,
+			Aliases:     f.tag.Aliases,
+			Deprecated:  f.tag.Deprecated,
+			Field:       f.path,
+			Type:        f.field.Type.String(),
+			Required:    !o.optional(f.tag) && len(f.tag.RequiredIf) == 0 && f.tag.Default == "",
+			Default:     f.tag.Default,
+			Description: f.tag.Description,
+			Secret:      isSecret(f.tag, ""),
+			Pattern:     f.pattern,
+			Tag:         f.field.Tag.Get(o.tagStyle().tagName()),
+		}
+
+		for _, c := range f.tag.RequiredIf {
+			spec.RequiredIf = append(spec.RequiredIf, c.String())
+		}
+
+		// fields of a struct gated by onlyIf are only read, and required,
+		// when all of its conditions hold
+		for _, c := range f.onlyIf {
+			spec.OnlyIf = append(spec.OnlyIf, "onlyIf="+c.Env+"="+c.Value)
+		}
+
+		if rule, ok := enumRule(f.field.Type); ok {
+			spec.Validators = append(spec.Validators, rule)
+		}
+		for _, rule := range f.tag.Rules {
+			spec.Validators = append(spec.Validators, rule.String())
+		}
+
+		specs = append(specs, spec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return specs, nil
+}
@@ -0,0 +1,191 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type describeTLS struct {
+	Cert string `env:"CERT"`
+	Key  string `env:"KEY,requiredIf=MODE=mutual"`
+}
+
+type describeConfig struct {
+	Port int         `env:"PORT,default=80"`
+	Mode string      `env:"MODE,optional"`
+	TLS  describeTLS `env:",onlyIf=TLS_ENABLED,onlyIf=SERVE=https" envPrefix:"TLS_"`
+}
+
+func TestDescribeOnlyIf(t *testing.T) {
+	specs, err := Describe(&describeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []VarSpec{
+		{Name: "PORT", Field: "Port", Type: "int", Default: "80", Tag: "PORT,default=80"},
+		{Name: "MODE", Field: "Mode", Type: "string", Tag: "MODE,optional"},
+		{
+			Name: "TLS_CERT", Field: "TLS.Cert", Type: "string", Required: true, Tag: "CERT",
+			OnlyIf: []string{"onlyIf=TLS_ENABLED=true", "onlyIf=SERVE=https"},
+		},
+		{
+			Name: "TLS_KEY", Field: "TLS.Key", Type: "string", Tag: "KEY,requiredIf=MODE=mutual",
+			RequiredIf: []string{"requiredIf=MODE=mutual"},
+			OnlyIf:     []string{"onlyIf=TLS_ENABLED=true", "onlyIf=SERVE=https"},
+		},
+	}
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("expected\n%+v\nfound\n%+v", want, specs)
+	}
+}
+
+func TestVerifyOnlyIf(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf, &describeConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := ReadSchema(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		vars    Map
+		missing []string
+	}{
+		{name: "inactive", vars: Map{}},
+		{name: "one condition holds", vars: Map{"TLS_ENABLED": "true", "SERVE": "http"}},
+		{name: "all conditions hold", vars: Map{"TLS_ENABLED": "1", "SERVE": "https"}, missing: []string{"TLS_CERT"}},
+		{name: "set", vars: Map{"TLS_ENABLED": "1", "SERVE": "https", "TLS_CERT": "c"}},
+		{
+			name:    "required inside",
+			vars:    Map{"TLS_ENABLED": "1", "SERVE": "https", "MODE": "mutual"},
+			missing: []string{"TLS_CERT", "TLS_KEY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(specs, WithLookuper(tt.vars), WithHermetic())
+
+			var missing []string
+			for _, e := range unwrapErrors(err) {
+				var m MissingError
+				if !errors.As(e, &m) {
+					t.Fatalf("unexpected error %v", e)
+				}
+				missing = append(missing, m.Env)
+			}
+			if !reflect.DeepEqual(missing, tt.missing) {
+				t.Errorf("expected missing %q, found %q", tt.missing, missing)
+			}
+
+			// Verify agrees with Parse
+			var cfg describeConfig
+			parseErr := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic())
+			if (parseErr == nil) != (err == nil) {
+				t.Errorf("Verify returned %v but Parse returned %v", err, parseErr)
+			}
+		})
+	}
+}
+
+func unwrapErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, unwrapErrors(e)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
+
+type describeServer struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT,default=80"`
+}
+
+type describeCluster struct {
+	Servers []describeServer           `envPrefix:"SERVERS_"`
+	Zones   map[string]*describeServer `envPrefix:"ZONE_"`
+}
+
+func TestDescribeElements(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  interface{}
+	}{
+		{name: "empty", obj: &describeCluster{}},
+		{name: "with elements", obj: &describeCluster{
+			Servers: []describeServer{{Host: "a"}, {Host: "b"}},
+			Zones:   map[string]*describeServer{"EU": {}},
+		}},
+	}
+
+	want := []VarSpec{
+		{Name: "APP_SERVERS_<n>_HOST", Field: "Servers[<n>].Host", Type: "string", Required: true, Pattern: true, Tag: "HOST"},
+		{Name: "APP_SERVERS_<n>_PORT", Field: "Servers[<n>].Port", Type: "int", Default: "80", Pattern: true, Tag: "PORT,default=80"},
+		{Name: "APP_ZONE_<key>_HOST", Field: "Zones[<key>].Host", Type: "string", Required: true, Pattern: true, Tag: "HOST"},
+		{Name: "APP_ZONE_<key>_PORT", Field: "Zones[<key>].Port", Type: "int", Default: "80", Pattern: true, Tag: "PORT,default=80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := Describe(tt.obj, WithPrefix("APP_"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(specs, want) {
+				t.Errorf("expected\n%+v\nfound\n%+v", want, specs)
+			}
+		})
+	}
+}
+
+func TestVerifySkipsPatterns(t *testing.T) {
+	specs, err := Describe(&describeCluster{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(specs, WithLookuper(Map{}), WithHermetic()); err != nil {
+		t.Errorf("expected patterns to be skipped, found %v", err)
+	}
+}
+
+func TestVerifyDefaultOptional(t *testing.T) {
+	type config struct {
+		Host  string `env:"HOST"`
+		Token string `env:"TOKEN,required"`
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf, &config{}, WithDefaultOptional()); err != nil {
+		t.Fatal(err)
+	}
+	specs, err := ReadSchema(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := Map{"TOKEN": "t"}
+	if err := ParseWithOptions(&config{}, WithLookuper(vars), WithHermetic(), WithDefaultOptional()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(specs, WithLookuper(vars), WithHermetic()); err != nil {
+		t.Errorf("expected the environment Parse accepts to verify, found %v", err)
+	}
+
+	err = Verify(specs, WithLookuper(Map{}), WithHermetic())
+	var missing MissingError
+	if !errors.As(err, &missing) || missing.Env != "TOKEN" {
+		t.Errorf("expected TOKEN to be missing, found %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// DirSource treats every file in a directory as a variable named after the
+// file, the layout Kubernetes uses for ConfigMap and Secret volumes. Files are
+// read on every lookup so updates are picked up without a restart.
+type DirSource struct {
+	fsys fs.FS
+}
+
+func Dir(path string) *DirSource {
+	return DirFS(os.DirFS(path))
+}
+
+func DirFS(fsys fs.FS) *DirSource {
+	return &DirSource{fsys: fsys}
+}
+
+func (d *DirSource) Lookup(key string) (string, bool) {
+	value, ok, _ := d.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (d *DirSource) LookupContext(_ context.Context, key string) (string, bool, error) {
+	if !fs.ValidPath(key) || strings.Contains(key, "/") || strings.HasPrefix(key, ".") {
+		return "", false, nil
+	}
+
+	content, err := fs.ReadFile(d.fsys, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error reading file '%s' : %w", key, err)
+	}
+
+	return trimNewline(string(content)), true, nil
+}
+
+func (d *DirSource) SourceName() string {
+	return "dir"
+}
+
+// Watch polls the directory every interval and calls onChange whenever a file
+// is added, removed or modified. It blocks until ctx is done.
+func (d *DirSource) Watch(ctx context.Context, interval time.Duration, onChange func()) error {
+	last, err := d.snapshot()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			next, err := d.snapshot()
+			if err != nil {
+				return err
+			}
+
+			if !sameSnapshot(last, next) {
+				last = next
+				onChange()
+			}
+		}
+	}
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func (d *DirSource) snapshot() (map[string]fileStamp, error) {
+	entries, err := fs.ReadDir(d.fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading dir : %w", err)
+	}
+
+	stamps := make(map[string]fileStamp, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		// stat through symlinks, Kubernetes swaps them atomically on update
+		info, err := fs.Stat(d.fsys, entry.Name())
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		stamps[entry.Name()] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	return stamps, nil
+}
+
+func sameSnapshot(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, stamp := range a {
+		other, ok := b[name]
+		if !ok || other.size != stamp.size || !other.modTime.Equal(stamp.modTime) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,124 @@
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDirSource(t *testing.T) {
+	d := DirFS(fstest.MapFS{
+		"DB_PASSWORD":       {Data: []byte("hunter2\n")},
+		"EMPTY":             {Data: []byte("")},
+		"nested/KEY":        {Data: []byte("nested")},
+		".hidden":           {Data: []byte("hidden")},
+		"..data/PASSWORD":   {Data: []byte("data")},
+		"MULTILINE.pem":     {Data: []byte("a\nb\n")},
+		"nested/.DS_Store":  {Data: []byte("")},
+		"nested/other/FILE": {Data: []byte("")},
+	})
+
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+		err  bool
+	}{
+		{key: "DB_PASSWORD", want: "hunter2", ok: true},
+		{key: "EMPTY", want: "", ok: true},
+		{key: "MULTILINE.pem", want: "a\nb", ok: true},
+		{key: "MISSING"},
+		{key: "nested/KEY"},
+		{key: "nested", err: true},
+		{key: ".hidden"},
+		{key: "../DB_PASSWORD"},
+		{key: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, ok, err := d.LookupContext(context.Background(), tt.key)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if value != tt.want || ok != tt.ok {
+				t.Errorf("expected '%s', %v, found '%s', %v", tt.want, tt.ok, value, ok)
+			}
+		})
+	}
+}
+
+func TestDirSourceWatch(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, value string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("HOST", "a")
+
+	d := Dir(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- d.Watch(ctx, 10*time.Millisecond, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+
+	// give the watcher time to take its first snapshot
+	time.Sleep(50 * time.Millisecond)
+	write("PORT", "80")
+
+	select {
+	case <-changed:
+	case err := <-done:
+		t.Fatalf("watch returned early : %v", err)
+	}
+
+	if value, ok := d.Lookup("PORT"); !ok || value != "80" {
+		t.Errorf("expected '80', found '%s', %v", value, ok)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled && err != context.DeadlineExceeded {
+		t.Errorf("expected the context error, found %v", err)
+	}
+}
+
+func TestSameSnapshot(t *testing.T) {
+	now := time.Now()
+	base := map[string]fileStamp{"A": {size: 1, modTime: now}}
+
+	tests := []struct {
+		name string
+		next map[string]fileStamp
+		want bool
+	}{
+		{name: "same", next: map[string]fileStamp{"A": {size: 1, modTime: now}}, want: true},
+		{name: "added", next: map[string]fileStamp{"A": {size: 1, modTime: now}, "B": {}}},
+		{name: "removed", next: map[string]fileStamp{}},
+		{name: "renamed", next: map[string]fileStamp{"B": {size: 1, modTime: now}}},
+		{name: "resized", next: map[string]fileStamp{"A": {size: 2, modTime: now}}},
+		{name: "modified", next: map[string]fileStamp{"A": {size: 1, modTime: now.Add(time.Second)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameSnapshot(base, tt.next); got != tt.want {
+				t.Errorf("expected %v, found %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteExample writes a .env.example file listing every variable obj
+// consumes. Defaults are filled in and required variables are left empty.
+func WriteExample(w io.Writer, obj interface{}, opts ...Option) error {
+	specs, err := Describe(obj, opts...)
+	if err != nil {
+		return err
+	}
+
+	for i, spec := range specs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if spec.Description != "" {
+			for _, line := range strings.Split(spec.Description, "\n") {
+				fmt.Fprintf(w, "# %s\n", line)
+			}
+		}
+
+		required := "optional"
+		switch {
+		case spec.Required:
+			required = "required"
+		case len(spec.RequiredIf) > 0:
+			required = strings.Join(spec.RequiredIf, ", ")
+		}
+		if len(spec.OnlyIf) > 0 {
+			required += ", " + strings.Join(spec.OnlyIf, ", ")
+		}
+		fmt.Fprintf(w, "# %s, %s\n", spec.Type, required)
+
+		value := spec.Default
+		if spec.Secret {
+			value = ""
+		}
+
+		// patterns are not valid names, they show the form of the variables
+		format := "%s=%s\n"
+		if spec.Pattern {
+			format = "# %s=%s\n"
+		}
+
+		if _, err := fmt.Fprintf(w, format, spec.Name, quoteDotenv(value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteMarkdown writes a Markdown table of every variable obj consumes.
+func WriteMarkdown(w io.Writer, obj interface{}, opts ...Option) error {
+	specs, err := Describe(obj, opts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "| Name | Type | Default | Required | Description |")
+	fmt.Fprintln(w, "| --- | --- | --- | --- | --- |")
+
+	for _, spec := range specs {
+		required := "no"
+		switch {
+		case spec.Required:
+			required = "yes"
+		case len(spec.RequiredIf) > 0:
+			required = "`" + strings.Join(spec.RequiredIf, "`, `") + "`"
+		}
+		if len(spec.OnlyIf) > 0 {
+			required += ", `" + strings.Join(spec.OnlyIf, "`, `") + "`"
+		}
+
+		def := ""
+		if spec.Default != "" && !spec.Secret {
+			def = "`" + spec.Default + "`"
+		}
+
+		_, err := fmt.Fprintf(w, "| `%s` | `%s` | %s | %s | %s |\n",
+			spec.Name, spec.Type, escapeCell(def), escapeCell(required), escapeCell(spec.Description))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func quoteDotenv(value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t\r\n#\"'\\$") && strings.TrimSpace(value) == value {
+		return value
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", `\r`, "\n", `\n`, `$`, `\$`)
+	return `"` + r.Replace(value) + `"`
+}
+
+func escapeCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", "<br>").Replace(value)
+}
@@ -0,0 +1,147 @@
+package env
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWriteExample(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteExample(&buf, &describeConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# int, optional
+PORT=80
+
+# string, optional
+MODE=
+
+# string, required, onlyIf=TLS_ENABLED=true, onlyIf=SERVE=https
+TLS_CERT=
+
+# string, requiredIf=MODE=mutual, onlyIf=TLS_ENABLED=true, onlyIf=SERVE=https
+TLS_KEY=
+`
+	if got := buf.String(); got != want {
+		t.Errorf("expected\n%s\nfound\n%s", want, got)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, &describeConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "| Name | Type | Default | Required | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `PORT` | `int` | `80` | no |  |\n" +
+		"| `MODE` | `string` |  | no |  |\n" +
+		"| `TLS_CERT` | `string` |  | yes, `onlyIf=TLS_ENABLED=true`, `onlyIf=SERVE=https` |  |\n" +
+		"| `TLS_KEY` | `string` |  | `requiredIf=MODE=mutual`, `onlyIf=TLS_ENABLED=true`, `onlyIf=SERVE=https` |  |\n"
+	if got := buf.String(); got != want {
+		t.Errorf("expected\n%s\nfound\n%s", want, got)
+	}
+}
+
+func TestQuoteDotenv(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"two words", `"two words"`},
+		{"a#b", `"a#b"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"$HOME", `"\$HOME"`},
+		{`back\slash`, `"back\\slash"`},
+		{"line1\nline2", `"line1\nline2"`},
+		{"cr\rlf", `"cr\rlf"`},
+		{"\fpadded", "\"\fpadded\""},
+	}
+
+	for _, tt := range tests {
+		if got := quoteDotenv(tt.value); got != tt.want {
+			t.Errorf("expected %q to be written as %q, found %q", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestWriteExamplePatterns(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteExample(&buf, &describeCluster{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# string, required
+# SERVERS_<n>_HOST=
+
+# int, optional
+# SERVERS_<n>_PORT=80
+
+# string, required
+# ZONE_<key>_HOST=
+
+# int, optional
+# ZONE_<key>_PORT=80
+`
+	if got := buf.String(); got != want {
+		t.Errorf("expected\n%s\nfound\n%s", want, got)
+	}
+}
+
+func TestWriteDocsDescriptions(t *testing.T) {
+	type config struct {
+		Token string        `env:"TOKEN,secret,default=s3cret" desc:"API token"`
+		Mode  string        `env:"MODE,default=a|b" desc:"first line\nsecond line"`
+		Wait  time.Duration `env:"WAIT,default=1m30s" desc:"how long to wait"`
+	}
+
+	tests := []struct {
+		name  string
+		write func(w io.Writer, obj interface{}, opts ...Option) error
+		want  string
+	}{
+		{
+			name:  "example",
+			write: WriteExample,
+			want: `# API token
+# string, optional
+TOKEN=
+
+# first line
+# second line
+# string, optional
+MODE=a|b
+
+# how long to wait
+# time.Duration, optional
+WAIT=1m30s
+`,
+		},
+		{
+			name:  "markdown",
+			write: WriteMarkdown,
+			want: "| Name | Type | Default | Required | Description |\n" +
+				"| --- | --- | --- | --- | --- |\n" +
+				"| `TOKEN` | `string` |  | no | API token |\n" +
+				"| `MODE` | `string` | `a\\|b` | no | first line<br>second line |\n" +
+				"| `WAIT` | `time.Duration` | `1m30s` | no | how long to wait |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf, &config{}); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("expected\n%s\nfound\n%s", tt.want, got)
+			}
+		})
+	}
+}
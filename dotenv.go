@@ -0,0 +1,245 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+func ReadDotenv(r io.Reader) (map[string]string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading dotenv : %w", err)
+	}
+
+	return parseDotenv(string(content))
+}
+
+func LoadDotenv(paths ...string) error {
+	vars, err := Options{}.loadDotenv(paths)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range vars {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("error exporting env '%s' : %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// loadDotenv merges the given files in order, later files overriding earlier
+// ones. Missing files are skipped so optional layers like .env.local can be
+// listed unconditionally.
+func (o Options) loadDotenv(paths []string) (map[string]string, error) {
+	vars := map[string]string{}
+
+	for _, path := range paths {
+		content, err := o.readFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := parseDotenv(content)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing dotenv '%s' : %w", path, err)
+		}
+
+		for key, value := range parsed {
+			vars[key] = value
+		}
+	}
+
+	return vars, nil
+}
+
+func parseDotenv(content string) (map[string]string, error) {
+	vars := map[string]string{}
+
+	p := dotenvParser{src: strings.ReplaceAll(content, "\r\n", "\n"), line: 1}
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return nil, fmt.Errorf("line %d : %w", p.line, err)
+		}
+		if !ok {
+			return vars, nil
+		}
+		vars[key] = value
+	}
+}
+
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+
+	// start, export and comment describe the last entry read for
+	// DotenvFile: where it starts, whether it has an export prefix and where
+	// its trailing comment starts, or -1.
+	start   int
+	export  bool
+	comment int
+}
+
+func (p *dotenvParser) next() (string, string, bool, error) {
+	for {
+		p.skipSpace()
+
+		if p.pos >= len(p.src) {
+			return "", "", false, nil
+		}
+
+		switch p.src[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+			continue
+
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		break
+	}
+
+	p.start, p.export, p.comment = p.pos, false, -1
+
+	rest := p.src[p.pos:]
+	if strings.HasPrefix(rest, "export ") || strings.HasPrefix(rest, "export\t") {
+		p.pos += len("export")
+		p.export = true
+		p.skipSpace()
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '=' && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+
+	if p.pos >= len(p.src) || p.src[p.pos] != '=' {
+		return "", "", false, fmt.Errorf("expected 'KEY=VALUE', found '%s'", strings.TrimSpace(p.src[start:p.pos]))
+	}
+
+	key := strings.TrimSpace(p.src[start:p.pos])
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", false, fmt.Errorf("invalid key '%s'", key)
+	}
+
+	p.pos++
+	p.skipSpace()
+
+	value, err := p.value()
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid value for '%s' : %w", key, err)
+	}
+
+	return key, value, true, nil
+}
+
+func (p *dotenvParser) value() (string, error) {
+	if p.pos >= len(p.src) {
+		return "", nil
+	}
+
+	switch quote := p.src[p.pos]; quote {
+	case '"', '\'':
+		p.pos++
+
+		var b strings.Builder
+		for {
+			if p.pos >= len(p.src) {
+				return "", fmt.Errorf("unterminated quoted value")
+			}
+
+			c := p.src[p.pos]
+			p.pos++
+
+			switch {
+			case c == quote:
+				return b.String(), p.endOfValue()
+
+			case c == '\n':
+				p.line++
+				b.WriteByte(c)
+
+			case c == '\\' && quote == '"' && p.pos < len(p.src):
+				b.WriteString(unescape(p.src[p.pos]))
+				p.pos++
+
+			default:
+				b.WriteByte(c)
+			}
+		}
+
+	default:
+		start := p.pos
+		p.skipLine()
+
+		value := p.src[start:p.pos]
+		for i := 1; i < len(value); i++ {
+			if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+				value = value[:i]
+				p.comment = start + len(strings.TrimRight(value, " \t"))
+				break
+			}
+		}
+
+		return strings.TrimSpace(value), nil
+	}
+}
+
+func (p *dotenvParser) endOfValue() error {
+	end := p.pos
+	p.skipSpace()
+
+	if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+		return nil
+	}
+
+	if p.src[p.pos] == '#' {
+		p.comment = end
+		p.skipLine()
+		return nil
+	}
+
+	return fmt.Errorf("unexpected characters after quoted value")
+}
+
+func (p *dotenvParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func unescape(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$':
+		return string(c)
+	}
+	return "\\" + string(c)
+}
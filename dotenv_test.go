@@ -0,0 +1,128 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		err     bool
+	}{
+		{name: "plain", content: "A=1\nB = two \n", want: map[string]string{"A": "1", "B": "two"}},
+		{name: "comments", content: "# comment\nA=1 # trailing\nB=a#b\n", want: map[string]string{"A": "1", "B": "a#b"}},
+		{name: "export", content: "export A=1\nexport\tB=2\n", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "double quotes", content: `A="a \"b\"\n\tc" # comment`, want: map[string]string{"A": "a \"b\"\n\tc"}},
+		{name: "single quotes", content: `A='a\nb $C'`, want: map[string]string{"A": `a\nb $C`}},
+		{name: "multiline", content: "A=\"line 1\nline 2\"\nB=3\n", want: map[string]string{"A": "line 1\nline 2", "B": "3"}},
+		{name: "crlf", content: "A=1\r\nB=2\r\n", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "empty", content: "A=\nB=''\n", want: map[string]string{"A": "", "B": ""}},
+		{name: "later wins", content: "A=1\nA=2\n", want: map[string]string{"A": "2"}},
+		{name: "missing equals", content: "A\n", err: true},
+		{name: "invalid key", content: "A B=1\n", err: true},
+		{name: "unterminated", content: "A=\"open\n", err: true},
+		{name: "after quote", content: "A=\"a\" b\n", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadDotenv(strings.NewReader(tt.content))
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, found %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithDotenv(t *testing.T) {
+	type config struct {
+		Host  string `env:"HOST"`
+		Port  string `env:"PORT"`
+		Debug string `env:"DEBUG,optional"`
+	}
+
+	fsys := fstest.MapFS{
+		".env":       {Data: []byte("HOST=base\nPORT=80\nDEBUG=false\n")},
+		".env.local": {Data: []byte("PORT=8080\n")},
+		".env.bad":   {Data: []byte("PORT\n")},
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		vars  Map
+		want  config
+		err   bool
+	}{
+		{
+			name:  "single file",
+			paths: []string{".env"},
+			want:  config{Host: "base", Port: "80", Debug: "false"},
+		},
+		{
+			name:  "later files override earlier ones",
+			paths: []string{".env", ".env.local"},
+			want:  config{Host: "base", Port: "8080", Debug: "false"},
+		},
+		{
+			name:  "missing files are skipped",
+			paths: []string{".env", ".env.missing"},
+			want:  config{Host: "base", Port: "80", Debug: "false"},
+		},
+		{
+			name:  "environment overrides dotenv",
+			paths: []string{".env", ".env.local"},
+			vars:  Map{"PORT": "9090"},
+			want:  config{Host: "base", Port: "9090", Debug: "false"},
+		},
+		{
+			name:  "invalid file",
+			paths: []string{".env", ".env.bad"},
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic(), WithFS(fsys), WithDotenv(tt.paths...))
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("LOAD_SET=dotenv\nLOAD_NEW=dotenv\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	unsetenv(t, "LOAD_NEW")
+	t.Setenv("LOAD_SET", "env")
+
+	if err := LoadDotenv(path, filepath.Join(dir, ".env.local")); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"LOAD_SET": "env", "LOAD_NEW": "dotenv"} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("expected %s to be '%s', found '%s'", key, want, got)
+		}
+	}
+}
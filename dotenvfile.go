@@ -0,0 +1,182 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// DotenvFile is a parsed dotenv file that keeps its comments, blank lines and
+// the text of every entry that is not changed, so tools can update files such
+// as .env.local without rewriting the rest.
+type DotenvFile struct {
+	entries []dotenvEntry
+
+	// tail is the text after the last entry.
+	tail string
+	crlf bool
+}
+
+type dotenvEntry struct {
+	// gap is the text between the previous entry and this one, starting
+	// with the newline that ends the previous entry.
+	gap string
+	raw string
+
+	key     string
+	value   string
+	export  bool
+	comment string
+}
+
+// ParseDotenvFile reads a dotenv file in the syntax ReadDotenv accepts.
+func ParseDotenvFile(r io.Reader) (*DotenvFile, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading dotenv : %w", err)
+	}
+
+	src := string(content)
+	f := &DotenvFile{crlf: strings.Contains(src, "\r\n") && strings.Count(src, "\r\n") == strings.Count(src, "\n")}
+
+	p := dotenvParser{src: strings.ReplaceAll(src, "\r\n", "\n"), line: 1}
+	end := 0
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return nil, fmt.Errorf("line %d : %w", p.line, err)
+		}
+		if !ok {
+			break
+		}
+
+		e := dotenvEntry{gap: p.src[end:p.start], raw: p.src[p.start:p.pos], key: key, value: value, export: p.export}
+		if p.comment >= 0 {
+			e.comment = p.src[p.comment:p.pos]
+		}
+		f.entries = append(f.entries, e)
+		end = p.pos
+	}
+	f.tail = p.src[end:]
+
+	return f, nil
+}
+
+// Get returns the value of key, the last one if it is set more than once.
+func (f *DotenvFile) Get(key string) (string, bool) {
+	if i := f.index(key); i >= 0 {
+		return f.entries[i].value, true
+	}
+	return "", false
+}
+
+// Set changes the value of key, keeping its export prefix and trailing
+// comment, or adds it at the end of the file. Keys that could not be read
+// back, such as those with whitespace, '=' or a leading '#', are rejected.
+func (f *DotenvFile) Set(key, value string) error {
+	if key == "" || strings.ContainsRune(key, '=') || strings.IndexFunc(key, unicode.IsSpace) >= 0 || strings.HasPrefix(key, "#") {
+		return fmt.Errorf("invalid key '%s'", key)
+	}
+
+	i := f.index(key)
+	if i < 0 {
+		e := dotenvEntry{key: key}
+		switch {
+		case strings.HasSuffix(f.tail, "\n"):
+			e.gap = f.tail
+		case len(f.entries) > 0 || f.tail != "":
+			e.gap = f.tail + "\n"
+		}
+		f.tail = "\n"
+		f.entries = append(f.entries, e)
+		i = len(f.entries) - 1
+	} else if f.entries[i].value == value {
+		return nil
+	}
+
+	e := &f.entries[i]
+	e.value = value
+	quoted := quoteDotenv(value)
+	if quoted == "" && e.comment != "" {
+		// an unquoted empty value would read the comment as the value
+		quoted = `""`
+	}
+	e.raw = key + "=" + quoted + e.comment
+	if e.export {
+		e.raw = "export " + e.raw
+	}
+	return nil
+}
+
+// Delete removes every entry of key. The comments above it are kept.
+func (f *DotenvFile) Delete(key string) {
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		if f.entries[i].key != key {
+			continue
+		}
+
+		// the gap of the next entry starts with the newline ending this one
+		gap := f.entries[i].gap
+		if i+1 < len(f.entries) {
+			f.entries[i+1].gap = gap + strings.TrimPrefix(f.entries[i+1].gap, "\n")
+		} else {
+			f.tail = gap + strings.TrimPrefix(f.tail, "\n")
+			if gap != "" && !strings.HasSuffix(f.tail, "\n") {
+				f.tail += "\n"
+			}
+		}
+		f.entries = append(f.entries[:i], f.entries[i+1:]...)
+	}
+}
+
+// Keys returns the keys in order of their first entry.
+func (f *DotenvFile) Keys() []string {
+	seen := map[string]bool{}
+
+	var keys []string
+	for _, e := range f.entries {
+		if !seen[e.key] {
+			seen[e.key] = true
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// Map returns the variables as ReadDotenv would.
+func (f *DotenvFile) Map() map[string]string {
+	vars := make(map[string]string, len(f.entries))
+	for _, e := range f.entries {
+		vars[e.key] = e.value
+	}
+	return vars
+}
+
+// WriteTo writes the file back, with CRLF line endings if the file only had
+// those and LF otherwise.
+func (f *DotenvFile) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, e := range f.entries {
+		b.WriteString(e.gap)
+		b.WriteString(e.raw)
+	}
+	b.WriteString(f.tail)
+
+	out := b.String()
+	if f.crlf {
+		out = strings.ReplaceAll(out, "\n", "\r\n")
+	}
+
+	n, err := io.WriteString(w, out)
+	return int64(n), err
+}
+
+func (f *DotenvFile) index(key string) int {
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		if f.entries[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,207 @@
+package env
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const dotenvFileSrc = `# header
+export A=1 # inline
+B = "two words" # c2
+
+  # indented comment
+C='single $x'
+M="line1
+line2"
+A=override
+`
+
+func writeDotenvFile(t testing.TB, f *DotenvFile) string {
+	t.Helper()
+
+	var b bytes.Buffer
+	if _, err := f.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestDotenvFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "empty", src: ""},
+		{name: "entries and comments", src: dotenvFileSrc},
+		{name: "no trailing newline", src: "A=1\nB=2"},
+		{name: "crlf", src: "A=1\r\n# c\r\nB=\"x y\"\r\n"},
+		{name: "only comments", src: "# a\n\n# b\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseDotenvFile(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := writeDotenvFile(t, f); got != tt.src {
+				t.Errorf("expected %q, found %q", tt.src, got)
+			}
+		})
+	}
+}
+
+func TestDotenvFileEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(f *DotenvFile) error
+		want string
+	}{
+		{
+			name: "set keeps export and comment",
+			edit: func(f *DotenvFile) error { return f.Set("A", "x y") },
+			want: "export A=\"x y\" # inline\nB=2   # c\n",
+		},
+		{
+			name: "set unchanged value keeps text",
+			edit: func(f *DotenvFile) error { return f.Set("B", "2") },
+			want: "export A=1 # inline\nB=2   # c\n",
+		},
+		{
+			name: "set empty value with comment",
+			edit: func(f *DotenvFile) error { return f.Set("B", "") },
+			want: "export A=1 # inline\nB=\"\"   # c\n",
+		},
+		{
+			name: "set appends",
+			edit: func(f *DotenvFile) error { return f.Set("C", "a\nb") },
+			want: "export A=1 # inline\nB=2   # c\nC=\"a\\nb\"\n",
+		},
+		{
+			name: "delete",
+			edit: func(f *DotenvFile) error { f.Delete("A"); return nil },
+			want: "B=2   # c\n",
+		},
+		{
+			name: "delete last",
+			edit: func(f *DotenvFile) error { f.Delete("B"); return nil },
+			want: "export A=1 # inline\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseDotenvFile(strings.NewReader("export A=1 # inline\nB=2   # c\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.edit(f); err != nil {
+				t.Fatal(err)
+			}
+			if got := writeDotenvFile(t, f); got != tt.want {
+				t.Errorf("expected %q, found %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDotenvFileSetInvalidKey(t *testing.T) {
+	for _, key := range []string{"", "A=B", "A B", "A\tB", "A\nB", "A\rB", "#A"} {
+		f := &DotenvFile{}
+		if err := f.Set(key, "value"); err == nil {
+			t.Errorf("expected an error for key %q", key)
+		}
+		if got := writeDotenvFile(t, f); got != "" {
+			t.Errorf("expected key %q to leave the file unchanged, found %q", key, got)
+		}
+	}
+}
+
+func TestDotenvFileGetKeysMap(t *testing.T) {
+	f, err := ParseDotenvFile(strings.NewReader(dotenvFileSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok := f.Get("A"); !ok || value != "override" {
+		t.Errorf("expected the last value of A, found %q, %v", value, ok)
+	}
+	if _, ok := f.Get("MISSING"); ok {
+		t.Errorf("expected MISSING to be unset")
+	}
+	if keys := f.Keys(); !reflect.DeepEqual(keys, []string{"A", "B", "C", "M"}) {
+		t.Errorf("unexpected keys %q", keys)
+	}
+
+	want, err := ReadDotenv(strings.NewReader(dotenvFileSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, found %q", want, got)
+	}
+}
+
+// FuzzParseDotenv checks that files the parser accepts are written back
+// unchanged when they only use LF line endings, and always read back to the
+// same variables.
+func FuzzParseDotenv(f *testing.F) {
+	for _, seed := range []string{dotenvFileSrc, "A=1", "export A='x' # c\r\n", "A=\"a\\nb\\\"\"\n", "#\n\n  B= c # d"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		file, err := ParseDotenvFile(strings.NewReader(src))
+		if err != nil {
+			return
+		}
+
+		out := writeDotenvFile(t, file)
+		if !strings.Contains(src, "\r") && out != src {
+			t.Fatalf("expected %q, found %q", src, out)
+		}
+
+		want, err := ReadDotenv(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("ParseDotenvFile accepted %q but ReadDotenv failed : %s", src, err)
+		}
+		got, err := ReadDotenv(strings.NewReader(out))
+		if err != nil {
+			t.Fatalf("error reading back %q : %s", out, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %q, found %q", want, got)
+		}
+	})
+}
+
+// FuzzDotenvFileSet checks that any value set on a file reads back
+// unchanged without disturbing the other variables.
+func FuzzDotenvFileSet(f *testing.F) {
+	f.Add("A", "x y")
+	f.Add("NEW", "a\r\nb\"c'd$e\\f # g")
+	f.Add("B", "")
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		file, err := ParseDotenvFile(strings.NewReader(dotenvFileSrc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := file.Map()
+
+		if err := file.Set(key, value); err != nil {
+			return
+		}
+		want[key] = value
+
+		got, err := ReadDotenv(strings.NewReader(writeDotenvFile(t, file)))
+		if err != nil {
+			t.Fatalf("error reading back key %q : %s", key, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %q, found %q", want, got)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+// Package dsn assembles database URLs from the variables services commonly
+// split them into, with DATABASE_URL overriding the whole group:
+//
+//	type Config struct {
+//		DB      dsn.Database
+//		Replica dsn.Database `envPrefix:"REPLICA_"`
+//	}
+//
+// After parsing, Config.DB.URL holds the URL from DATABASE_URL or, when it is
+// not set, the one built from DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME
+// and DB_SSLMODE. The replica reads the same names under REPLICA_.
+package dsn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// Database is a struct to nest in a configuration. Its Validate method,
+// called by Parse once the variables are read, resolves URL.
+type Database struct {
+	DatabaseURL string `env:"DATABASE_URL,optional,secret" desc:"database URL, overriding the DB_ variables"`
+
+	Scheme   string `env:"DB_SCHEME,default=postgres" desc:"database URL scheme"`
+	Host     string `env:"DB_HOST,optional" desc:"database host, required without DATABASE_URL"`
+	Port     int    `env:"DB_PORT,optional,min=1,max=65535" desc:"database port"`
+	User     string `env:"DB_USER,optional" desc:"database user"`
+	Password string `env:"DB_PASSWORD,optional,secret" desc:"database password"`
+	Name     string `env:"DB_NAME,optional" desc:"database name"`
+	SSLMode  string `env:"DB_SSLMODE,optional" desc:"sslmode query parameter"`
+
+	// URL is the resolved URL, set by Validate.
+	URL *url.URL `env:"-"`
+}
+
+// Validate resolves URL from DATABASE_URL or the DB_ variables. Errors do not
+// include the URL, which usually holds a password.
+func (d *Database) Validate() error {
+	if d.DatabaseURL != "" {
+		u, err := url.Parse(d.DatabaseURL)
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		if err != nil {
+			return fmt.Errorf("error parsing DATABASE_URL : %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("error parsing DATABASE_URL : expected 'scheme://host/name'")
+		}
+
+		d.URL = u
+		return nil
+	}
+
+	if d.Host == "" {
+		return fmt.Errorf("missing database host, expected DATABASE_URL or DB_HOST")
+	}
+
+	u := &url.URL{Scheme: d.Scheme, Host: d.Host}
+	if d.Port != 0 {
+		u.Host = net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	}
+	if d.Name != "" {
+		u.Path = "/" + d.Name
+	}
+
+	switch {
+	case d.User != "" && d.Password != "":
+		u.User = url.UserPassword(d.User, d.Password)
+	case d.User != "":
+		u.User = url.User(d.User)
+	}
+
+	if d.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {d.SSLMode}}.Encode()
+	}
+
+	d.URL = u
+	return nil
+}
+
+// DSN returns the resolved URL as a string, or an empty string before
+// parsing.
+func (d Database) DSN() string {
+	if d.URL == nil {
+		return ""
+	}
+	return d.URL.String()
+}
+
+// String returns the resolved URL with the password redacted, for logs.
+func (d Database) String() string {
+	if d.URL == nil {
+		return ""
+	}
+	return d.URL.Redacted()
+}
@@ -0,0 +1,61 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const Redacted = "******"
+
+// Dump renders the configuration held by obj as KEY=value lines, with the
+// values of fields tagged secret or file redacted.
+func Dump(obj interface{}, opts ...Option) string {
+	var b strings.Builder
+	for _, kv := range dump(obj, newOptions(opts)) {
+		fmt.Fprintf(&b, "%s=%s\n", kv[0], kv[1])
+	}
+	return b.String()
+}
+
+func DumpMap(obj interface{}, opts ...Option) map[string]string {
+	m := map[string]string{}
+	for _, kv := range dump(obj, newOptions(opts)) {
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
+
+func dump(obj interface{}, opts Options) [][2]string {
+	var kvs [][2]string
+
+	_ = walk(reflect.ValueOf(obj), opts, func(f fieldInfo) error {
+		value, err := opts.formatValue(f.value, f.tag)
+		switch {
+		case isSecret(f.tag, ""):
+			value = redact(value)
+
+		case err != nil:
+			value = fmt.Sprint(f.value.Interface())
+		}
+
+		kvs = append(kvs, [2]string{f.tag.Env, value})
+		return nil
+	})
+
+	return kvs
+}
+
+// isSecret reports whether the value of a field read from source is
+// redacted: fields tagged secret, file or encrypted and values read from
+// files.
+func isSecret(tag Tag, source string) bool {
+	return tag.Secret || tag.File || tag.Encrypted || source == SourceFile
+}
+
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return Redacted
+}
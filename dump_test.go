@@ -0,0 +1,53 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDumpMap(t *testing.T) {
+	type config struct {
+		Host     string `env:"HOST"`
+		Password string `env:"PASSWORD,secret"`
+		Key      string `env:"KEY,file"`
+		Empty    string `env:"EMPTY,secret"`
+		Port     int    `env:"PORT"`
+	}
+
+	tests := []struct {
+		name string
+		obj  interface{}
+		want map[string]string
+	}{
+		{
+			name: "redacts secret and file fields",
+			obj:  &config{Host: "localhost", Password: "hunter2", Key: "-----BEGIN KEY-----", Port: 80},
+			want: map[string]string{"HOST": "localhost", "PASSWORD": Redacted, "KEY": Redacted, "EMPTY": "", "PORT": "80"},
+		},
+		{
+			name: "keeps empty secrets empty",
+			obj:  &config{},
+			want: map[string]string{"HOST": "", "PASSWORD": "", "KEY": "", "EMPTY": "", "PORT": "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DumpMap(tt.obj); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, found %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDump(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+		Key  string `env:"KEY,file"`
+	}
+
+	got := Dump(&config{Host: "localhost", Key: "contents"})
+	if want := "HOST=localhost\nKEY=" + Redacted + "\n"; got != want {
+		t.Errorf("expected %q, found %q", want, got)
+	}
+}
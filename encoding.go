@@ -0,0 +1,73 @@
+package env
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// Encodings accepted by the encoding tag option for []byte fields. Without
+// one the raw value is used.
+const (
+	EncodingBase64    = "base64"
+	EncodingBase64URL = "base64url"
+	EncodingHex       = "hex"
+)
+
+func isEncoding(name string) bool {
+	switch name {
+	case EncodingBase64, EncodingBase64URL, EncodingHex:
+		return true
+	}
+	return false
+}
+
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+// decodeBytes decodes value with the given encoding, accepting base64 with or
+// without padding.
+func decodeBytes(value, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(value), nil
+
+	case EncodingBase64:
+		if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+			return decoded, nil
+		}
+		return base64.RawStdEncoding.DecodeString(value)
+
+	case EncodingBase64URL:
+		if decoded, err := base64.URLEncoding.DecodeString(value); err == nil {
+			return decoded, nil
+		}
+		return base64.RawURLEncoding.DecodeString(value)
+
+	case EncodingHex:
+		return hex.DecodeString(value)
+	}
+
+	return nil, fmt.Errorf("unknown encoding '%s'", encoding)
+}
+
+func encodeBytes(value []byte, encoding string) string {
+	switch encoding {
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(value)
+
+	case EncodingBase64URL:
+		return base64.URLEncoding.EncodeToString(value)
+
+	case EncodingHex:
+		return hex.EncodeToString(value)
+	}
+
+	return string(value)
+}
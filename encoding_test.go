@@ -0,0 +1,58 @@
+package env
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncodedBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		typ   reflect.Type
+		tag   reflect.StructTag
+		value string
+		want  interface{}
+		err   bool
+	}{
+		{name: "raw", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V"`, value: "key", want: []byte("key")},
+		{name: "base64", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V,encoding=base64"`, value: "AP8Q", want: []byte{0, 255, 16}},
+		{name: "base64 unpadded", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V,encoding=base64"`, value: "AP8", want: []byte{0, 255}},
+		{name: "base64url", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V,encoding=base64url"`, value: "_-8=", want: []byte{255, 239}},
+		{name: "hex", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V,encoding=hex"`, value: "00ff10", want: []byte{0, 255, 16}},
+		{name: "array", typ: reflect.TypeOf([4]byte{}), tag: `env:"V,encoding=hex"`, value: "deadbeef", want: [4]byte{0xde, 0xad, 0xbe, 0xef}},
+		{name: "array length", typ: reflect.TypeOf([4]byte{}), tag: `env:"V,encoding=hex"`, value: "dead", err: true},
+		{name: "invalid base64", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V,encoding=base64"`, value: "!!", err: true},
+		{name: "invalid hex", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V,encoding=hex"`, value: "0g", err: true},
+		{name: "unknown encoding", typ: reflect.TypeOf([]byte(nil)), tag: `env:"V,encoding=base32"`, value: "a", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: tt.typ, Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			err := ParseFromMap(v.Interface(), map[string]string{"V": tt.value})
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if got := v.Elem().Field(0).Interface(); err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, found %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeBytes(t *testing.T) {
+	value := []byte{0, 255, 16, 'a'}
+
+	for _, encoding := range []string{"", EncodingBase64, EncodingBase64URL, EncodingHex} {
+		decoded, err := decodeBytes(encodeBytes(value, encoding), encoding)
+		if err != nil {
+			t.Fatalf("%s : %v", encoding, err)
+		}
+		if !bytes.Equal(decoded, value) {
+			t.Errorf("expected %s to read back %v, found %v", encoding, value, decoded)
+		}
+	}
+}
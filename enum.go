@@ -0,0 +1,68 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	enumsMu sync.RWMutex
+	enums   = map[reflect.Type][]string{}
+)
+
+// Enum returns a parser for T that only accepts the given values, for use
+// with WithParsers. Other values fail with an error listing the valid ones.
+func Enum[T ~string](values ...T) ParserFunc {
+	allowed := enumValues(values)
+
+	return func(value string) (interface{}, error) {
+		for _, v := range values {
+			if value == string(v) {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid value '%s', expected one of '%s'", value, strings.Join(allowed, "', '"))
+	}
+}
+
+// RegisterEnum restricts every field of type T to the given values, such as
+// the levels of a LOG_LEVEL field. Describe lists them as a oneof validator.
+func RegisterEnum[T ~string](values ...T) {
+	t := reflect.TypeOf(T(""))
+	RegisterParser(t, Enum(values...))
+
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+
+	enums[t] = enumValues(values)
+}
+
+func enumValues[T ~string](values []T) []string {
+	allowed := make([]string, len(values))
+	for i, v := range values {
+		allowed[i] = string(v)
+	}
+	return allowed
+}
+
+// enumRule returns the values registered for t, or its element type, as the
+// equivalent oneof rule.
+func enumRule(t reflect.Type) (string, bool) {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+
+	for {
+		if values, ok := enums[t]; ok {
+			return "oneof=" + strings.Join(values, "|"), true
+		}
+
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		default:
+			return "", false
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type enumLevel string
+
+type enumMode string
+
+func TestEnum(t *testing.T) {
+	levelType := reflect.TypeOf(enumLevel(""))
+
+	testSetValue(t, levelType, []setValueTest{
+		{value: "info", want: enumLevel("info")},
+		{value: "debug", want: enumLevel("debug")},
+		{value: "INFO", err: true},
+		{value: "", err: true},
+	}, WithParsers(map[reflect.Type]ParserFunc{levelType: Enum[enumLevel]("debug", "info")}))
+
+	_, err := Enum[enumLevel]("debug", "info")("trace")
+	if want := "invalid value 'trace', expected one of 'debug', 'info'"; err == nil || err.Error() != want {
+		t.Errorf("expected %q, found %v", want, err)
+	}
+}
+
+func TestRegisterEnum(t *testing.T) {
+	modeType := reflect.TypeOf(enumMode(""))
+
+	RegisterEnum[enumMode]("dev", "prod")
+	t.Cleanup(func() {
+		parsersMu.Lock()
+		delete(parsers, modeType)
+		parsersMu.Unlock()
+
+		enumsMu.Lock()
+		delete(enums, modeType)
+		enumsMu.Unlock()
+	})
+
+	type config struct {
+		Mode  enumMode   `env:"MODE"`
+		Modes []enumMode `env:"MODES,optional"`
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		var cfg config
+		if err := ParseFromMap(&cfg, map[string]string{"MODE": "dev", "MODES": "dev,prod"}); err != nil {
+			t.Fatal(err)
+		}
+		if want := (config{Mode: "dev", Modes: []enumMode{"dev", "prod"}}); !reflect.DeepEqual(cfg, want) {
+			t.Errorf("expected %+v, found %+v", want, cfg)
+		}
+
+		err := ParseFromMap(&cfg, map[string]string{"MODE": "test"})
+		if err == nil || !strings.Contains(err.Error(), "expected one of 'dev', 'prod'") {
+			t.Errorf("expected the valid values in %v", err)
+		}
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		specs, err := Describe(&config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, spec := range specs {
+			if want := []string{"oneof=dev|prod"}; !reflect.DeepEqual(spec.Validators, want) {
+				t.Errorf("expected validators %q for '%s', found %q", want, spec.Name, spec.Validators)
+			}
+		}
+	})
+}
+
+func TestOneofError(t *testing.T) {
+	type config struct {
+		Region string `env:"REGION,oneof=eu|us"`
+	}
+
+	var cfg config
+	err := ParseFromMap(&cfg, map[string]string{"REGION": "ap"})
+	if err == nil || !strings.Contains(err.Error(), "expected one of 'eu', 'us'") {
+		t.Errorf("expected the valid values in %v", err)
+	}
+}
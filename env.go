@@ -1,135 +1,1057 @@
 package env
 
 import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
-const TagName = "env"
+const (
+	TagName       = "env"
+	PrefixTagName = "envPrefix"
+	DescTagName   = "desc"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	stringType          = reflect.TypeOf("")
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
 
 func Parse(obj interface{}) error {
+	return ParseWithOptions(obj)
+}
+
+func ParseWithOptions(obj interface{}, opts ...Option) error {
+	return ParseContext(context.Background(), obj, opts...)
+}
+
+func ParseContext(ctx context.Context, obj interface{}, opts ...Option) error {
+	return parse(ctx, obj, newOptions(opts))
+}
+
+func MustParse(obj interface{}, opts ...Option) {
+	if err := ParseWithOptions(obj, opts...); err != nil {
+		panic(diagnostics(err))
+	}
+}
+
+func diagnostics(err error) string {
+	var b strings.Builder
+	b.WriteString("env: invalid configuration")
 
-	t := reflect.TypeOf(obj)
-	for t.Kind() == reflect.Ptr {
-		t = t.Elem()
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	for _, err := range errs {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+// ParseEnviron parses obj from environ alone, a list of "KEY=value" entries
+// such as os.Environ or exec.Cmd.Env. Later entries override earlier ones
+// with the same key.
+func ParseEnviron(environ []string, obj interface{}) error {
+	return ParseWithOptions(obj, WithVars(parseEnviron(environ)), WithHermetic())
+}
+
+// ParseFromEnviron is ParseEnviron with the arguments in the order of
+// ParseFromMap.
+func ParseFromEnviron(obj interface{}, environ []string) error {
+	return ParseEnviron(environ, obj)
+}
+
+func ParseFromMap(obj interface{}, vars map[string]string) error {
+	return ParseWithOptions(obj, WithLookuper(Map(vars)), WithHermetic())
+}
+
+func parseEnviron(environ []string) map[string]string {
+	vars := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			continue
+		}
+		vars[key] = value
+	}
+	return vars
+}
+
+func parse(ctx context.Context, obj interface{}, opts Options) error {
+	if opts.Hermetic && opts.ExportResolved {
+		return fmt.Errorf("export of resolved values is not permitted in hermetic mode")
+	}
+
+	if err := opts.load(); err != nil {
+		return err
 	}
 
 	v := reflect.ValueOf(obj)
-	for v.Kind() == reflect.Ptr {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
 		v = v.Elem()
 	}
 
-	for i := 0; i < t.NumField(); i++ {
+	if v.Kind() != reflect.Struct || !v.CanSet() {
+		return ErrInvalidTarget
+	}
+
+	// a schema was checked when compiled
+	if opts.plan == nil {
+		if err := opts.conflicts(v.Type()); err != nil {
+			return err
+		}
+	}
+
+	d := newDecoder(ctx, opts)
 
-		tField := t.Field(i)
+	errs := d.parseStruct(v, scope{prefix: opts.Prefix})
+	if opts.Strict {
+		errs = append(errs, d.unknown()...)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if errs := d.validateStruct(v, ""); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if opts.ExportResolved {
+		for key, value := range d.exports {
+			if err := os.Setenv(key, value); err != nil {
+				return fmt.Errorf("error exporting env '%s' : %w", key, err)
+			}
+		}
+	}
+
+	for _, key := range d.unset {
+		if err := os.Unsetenv(key); err != nil {
+			return fmt.Errorf("error unsetting env '%s' : %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// load reads the dotenv files and takes the snapshots that lookups resolve
+// from.
+func (o *Options) load() error {
+	// without a prefix every variable of the environment would be unknown
+	if o.Strict && o.Prefix == "" {
+		return fmt.Errorf("strict mode requires a prefix")
+	}
+
+	if len(o.Dotenv) > 0 {
+		vars, err := o.loadDotenv(o.Dotenv)
+		if err != nil {
+			return err
+		}
+		o.dotenv = vars
+	}
+	if o.Snapshot {
+		o.snapshot()
+	}
+	o.fold()
+	return nil
+}
+
+type decoder struct {
+	ctx     context.Context
+	opts    Options
+	exports map[string]string
+
+	// consumed holds every name a field may be read from, checked in strict
+	// mode.
+	consumed map[string]bool
+
+	// resolved holds the raw value of every field parsed so far, which
+	// defaults can refer to.
+	resolved map[string]string
+
+	// unset holds variables to remove from the process environment once
+	// parsing succeeds.
+	unset []string
+
+	// inactive holds the paths of nested structs skipped by onlyIf, which
+	// are not validated.
+	inactive map[string]bool
+
+	// found records whether any variable was present, which decides if nil
+	// struct pointers get allocated.
+	found bool
+
+	// buffered is set for child decoders, whose callbacks are held in
+	// pending until merge so that structs not kept report nothing.
+	buffered bool
+	pending  []func()
+}
+
+func newDecoder(ctx context.Context, opts Options) *decoder {
+	d := &decoder{
+		ctx:      ctx,
+		opts:     opts,
+		resolved: map[string]string{},
+	}
+	if opts.Strict {
+		d.consumed = map[string]bool{}
+	}
+	return d
+}
+
+// consume records a name a field may be read from, only needed by strict
+// mode.
+func (d *decoder) consume(name string) {
+	if d.consumed != nil {
+		d.consumed[name] = true
+	}
+}
+
+// emit runs fn, a callback reporting on a field, or holds it until merge.
+func (d *decoder) emit(fn func()) {
+	if d.buffered {
+		d.pending = append(d.pending, fn)
+		return
+	}
+	fn()
+}
+
+// export records a resolved value, only needed when exporting.
+func (d *decoder) export(key, value string) {
+	if !d.opts.ExportResolved {
+		return
+	}
+	if d.exports == nil {
+		d.exports = map[string]string{}
+	}
+	d.exports[key] = value
+}
+
+func (d *decoder) parseStruct(v reflect.Value, s scope) []error {
+	s = s.enter(v.Type())
+
+	var errs []error
+	for i, f := range fieldsOf(v.Type(), d.opts.tagStyle()) {
+		tField := f.StructField
 		vField := v.Field(i)
 
-		if !vField.CanSet() {
+		switch d.opts.plan.kind(v.Type(), i, f, d.opts) {
+		case fieldNested:
+			active, err := d.holds(f.tag.OnlyIf, s.prefix)
+			if err != nil {
+				errs = append(errs, LookupError{Env: f.tag.OnlyIf[0].Env, Field: joinPath(s.path, tField.Name), Err: err})
+				continue
+			}
+			if !active {
+				d.skip(vField, s.nested(tField))
+				if d.inactive == nil {
+					d.inactive = map[string]bool{}
+				}
+				d.inactive[joinPath(s.path, tField.Name)] = true
+				continue
+			}
+			errs = append(errs, d.parseNested(vField, s.nested(tField))...)
+
+		case fieldIndexed:
+			errs = append(errs, d.parseIndexed(vField, tField, s)...)
+
+		case fieldKeyed:
+			errs = append(errs, d.parseKeyed(vField, tField, s)...)
+
+		case fieldValue:
+			if err := d.parseField(f, vField, s); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
+
+// skip leaves a nested struct whose onlyIf conditions do not hold untouched,
+// marking its variables as consumed so strict mode does not report them.
+func (d *decoder) skip(v reflect.Value, s scope) {
+	_ = walkStruct(v, s, d.opts, true, func(f fieldInfo) error {
+		for _, name := range f.tag.Names() {
+			d.consume(name)
+		}
+		return nil
+	})
+}
+
+func (d *decoder) parseNested(v reflect.Value, s scope) []error {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		if s.recursive(v.Type()) {
+			return nil
+		}
+
+		nested := d.child()
+
+		ptr := reflect.New(v.Type().Elem())
+		errs := nested.parseStruct(ptr.Elem(), s)
+		if !nested.found && !d.opts.AllocateStructs {
+			return nil
+		}
+
+		d.merge(nested)
+
+		v.Set(ptr)
+		return errs
+	}
+
+	if nested, ok := structValue(v); ok {
+		return d.parseStruct(nested, s)
+	}
+
+	return nil
+}
+
+// parseIndexed fills a slice of structs from variables numbered from zero,
+// such as UPSTREAM_0_HOST, stopping at the first index with nothing set.
+// Slices of an enclosing struct type are left alone, as finding the first
+// index with nothing set would descend into them without end.
+func (d *decoder) parseIndexed(v reflect.Value, tField reflect.StructField, s scope) []error {
+	if s.recursive(v.Type().Elem()) {
+		return nil
+	}
+
+	slice := reflect.MakeSlice(v.Type(), 0, 0)
+
+	var errs []error
+	for i := 0; ; i++ {
+		nested := d.child()
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		elemErrs := nested.parseNested(elem, s.index(tField, i))
+		if !nested.found {
+			break
+		}
+
+		d.merge(nested)
+
+		errs = append(errs, elemErrs...)
+		slice = reflect.Append(slice, elem)
+	}
+
+	if slice.Len() > 0 {
+		v.Set(slice)
+	}
+	return errs
+}
+
+// parseKeyed fills a map of structs from variables named
+// <PREFIX><KEY>_<NAME>, finding the keys by listing the variables of every
+// source that implements KeyLister.
+func (d *decoder) parseKeyed(v reflect.Value, tField reflect.StructField, s scope) []error {
+	prefix := s.prefix + tField.Tag.Get(PrefixTagName)
+
+	keys, err := d.mapKeys(prefix, v.Type().Elem())
+	if err != nil {
+		return []error{fmt.Errorf("error parsing tag of field '%s' : %w", joinPath(s.path, tField.Name), err)}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	m := reflect.MakeMapWithSize(v.Type(), len(keys))
+
+	var errs []error
+	for _, key := range keys {
+		nested := d.child()
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		errs = append(errs, nested.parseNested(elem, s.key(tField, key))...)
+
+		d.merge(nested)
+		m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	}
+
+	v.Set(m)
+	return errs
+}
+
+// mapKeys finds the keys of variables named <prefix><KEY>_<NAME>, where NAME
+// is one of the names the struct type t reads.
+func (d *decoder) mapKeys(prefix string, t reflect.Type) ([]string, error) {
+	opts := d.opts
+	opts.Prefix = ""
+
+	var names []string
+	err := walkAll(reflect.New(t), opts, func(f fieldInfo) error {
+		for _, name := range f.tag.Names() {
+			names = append(names, name)
+			if d.opts.FileFallback {
+				names = append(names, name+FileSuffix)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, env := range d.opts.keys() {
+		if !strings.HasPrefix(env, prefix) {
 			continue
 		}
 
-		tag, ok, err := parseTag(tField.Tag)
-		if err != nil {
-			return fmt.Errorf("error parsing field '%s' : %w", tField.Name, err)
+		// with fields HOST and DB_HOST, ACME_DB_HOST is the DB_HOST of ACME
+		rest := env[len(prefix):]
+		longest := ""
+		for _, name := range names {
+			if len(name) > len(longest) && len(rest) > len(name)+1 && strings.HasSuffix(rest, "_"+name) {
+				longest = name
+			}
+		}
+		if longest != "" {
+			seen[rest[:len(rest)-len(longest)-1]] = true
 		}
+	}
 
-		if !ok {
+	var keys []string
+	for key := range seen {
+		if !isKeyPrefix(key, seen, names) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// isKeyPrefix reports whether key is another key followed by the start of a
+// name, such as ACME_DB for ACME and DB_HOST, whose variables are read as
+// those of the other key.
+func isKeyPrefix(key string, keys map[string]bool, names []string) bool {
+	for i := 1; i < len(key)-1; i++ {
+		if key[i] != '_' || !keys[key[:i]] {
 			continue
 		}
+		for _, name := range names {
+			if strings.HasPrefix(name, key[i+1:]+"_") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// child returns a decoder for a struct that is only kept if any of its
+// variables are set, see merge.
+func (d *decoder) child() *decoder {
+	// maps written by nested structs must be shared
+	if d.inactive == nil {
+		d.inactive = map[string]bool{}
+	}
+
+	nested := *d
+	nested.exports = nil
+	nested.unset = nil
+	nested.found = false
+	nested.buffered = true
+	nested.pending = nil
+	return &nested
+}
+
+func (d *decoder) merge(nested *decoder) {
+	for key, value := range nested.exports {
+		d.export(key, value)
+	}
+	d.unset = append(d.unset, nested.unset...)
+	d.found = d.found || nested.found
+	for _, fn := range nested.pending {
+		d.emit(fn)
+	}
+}
+
+func structValue(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
 
-		value := os.Getenv(tag.Env)
+	return v, v.Kind() == reflect.Struct
+}
+
+func (d *decoder) parseField(f field, vField reflect.Value, s scope) (err error) {
+	path := joinPath(s.path, f.Name)
 
-		if value == "" {
-			value = tag.Default
+	tag, planned := d.opts.plan.tag(path)
+	if !planned {
+		if tag, err = s.tag(f, d.opts); err != nil {
+			return fmt.Errorf("error parsing tag of field '%s' : %w", path, err)
 		}
+	}
 
-		if value == "" && !tag.Optional {
-			return fmt.Errorf("missing required env : %s", tag.Env)
+	for _, name := range tag.Names() {
+		d.consume(name)
+		if d.opts.FileFallback {
+			d.consume(name + FileSuffix)
 		}
+	}
+
+	env, value, source, ok, err := d.lookupNames(tag, path)
+	if err != nil {
+		return err
+	}
+
+	if d.opts.report != nil {
+		defer func() {
+			d.record(FieldReport{Field: path, Env: env, Set: ok, Source: source, Value: value, Secret: isSecret(tag, source)})
+		}()
+	}
+	if d.opts.Logger != nil {
+		defer func() {
+			d.trace(path, tag, env, source, vField, err)
+		}()
+	}
+
+	if ok && d.opts.OnDeprecated != nil && tag.isDeprecated(env) {
+		d.deprecated(FieldInfo{Path: path, Env: tag.Env, Type: vField.Type(), Tag: tag}, env)
+	}
+
+	if source == SourceEnv && (tag.Unset || d.opts.UnsetAfterRead) {
+		d.unset = append(d.unset, env)
+	}
+
+	if ok && value == "" {
+		switch {
+		case tag.NotEmpty:
+			return EmptyError{Env: env, Field: path, Description: tag.Description}
+
+		case tag.AllowEmpty || d.opts.AllowEmpty:
+			d.found = true
+			vField.Set(reflect.Zero(vField.Type()))
+			d.onSet(path, env, vField, tag, value, source)
+			return nil
+		}
+	}
+
+	if value != "" {
+		d.found = true
+	}
 
-		switch vField.Kind() {
-		case reflect.Slice:
-			values, err := parseSlice(value, vField.Type().Elem())
+	if value == "" && (tag.Keep || d.opts.KeepExisting) && !vField.IsZero() {
+		// the current value acts as the default, formatted for reports
+		// and references from other defaults
+		value, _ = d.opts.formatValue(vField, tag)
+		source = SourceExisting
+		d.resolved[tag.Env] = value
+		d.onSet(path, env, vField, tag, value, source)
+		return nil
+	}
+
+	if value == "" && tag.Default != "" {
+		if value, err = d.expandDefault(tag.Default, s.prefix); err != nil {
+			return LookupError{Env: env, Field: path, Err: err}
+		}
+		source = SourceDefault
+	}
+
+	decrypted := false
+	if value, decrypted, err = d.opts.decrypt(value, tag); err != nil {
+		return ParseError{Env: env, Field: path, Err: err}
+	}
+	if decrypted {
+		// the plaintext is treated as a secret
+		tag.Secret = true
+	}
+
+	if tag.Expand {
+		if value, err = d.expand(value); err != nil {
+			return LookupError{Env: env, Field: path, Err: err}
+		}
+	}
+
+	// file contents and decrypted values are usually secrets and are never
+	// exported
+	if value != "" && source != SourceEnv && source != SourceFile && !tag.File && !decrypted {
+		d.export(tag.Env, value)
+	}
+
+	if value == "" {
+		// the conditions of requiredIf are only looked up for missing values
+		required, err := d.required(tag, s.prefix)
+		if err != nil {
+			return LookupError{Env: env, Field: path, Err: err}
+		}
+		if required {
+			return MissingError{Env: tag.Env, Field: path, Aliases: tag.Aliases, Deprecated: tag.Deprecated, Description: tag.Description}
+		}
+		return nil
+	}
+
+	if tag.File {
+		if value, err = d.opts.readFile(value); err != nil {
+			return ParseError{Env: env, Field: path, Err: err}
+		}
+		source = SourceFile
+	}
+
+	d.resolved[tag.Env] = value
+
+	if err := d.setField(vField, value, tag); err != nil {
+		if errors.Is(err, ErrUnsupported) {
+			return UnsupportedError{Env: env, Field: path, Type: vField.Type()}
+		}
+		return ParseError{Env: env, Field: path, Err: d.opts.sanitize(err, value, tag)}
+	}
+
+	for _, rule := range tag.Rules {
+		if err := rule.check(vField, value, d.opts); err != nil {
+			if d.opts.sanitized(tag) {
+				return RuleError{Env: env, Field: path, Rule: rule.String(), Value: redact(value), Err: d.opts.sanitize(err, value, tag)}
+			}
+			return RuleError{Env: env, Field: path, Rule: rule.String(), Value: value, Err: err}
+		}
+	}
+
+	d.onSet(path, env, vField, tag, value, source)
+	return nil
+}
+
+func (d *decoder) onSet(path, env string, v reflect.Value, tag Tag, value, source string) {
+	if d.opts.OnSet == nil {
+		return
+	}
+
+	if isSecret(tag, source) {
+		value = redact(value)
+	}
+
+	info := FieldInfo{Path: path, Env: env, Type: v.Type(), Tag: tag}
+	d.emit(func() { d.opts.OnSet(info, value, source) })
+}
+
+func (d *decoder) deprecated(info FieldInfo, env string) {
+	d.emit(func() { d.opts.OnDeprecated(info, env) })
+}
+
+// lookupNames tries each of the tag's names in order and returns the first one
+// that is set, falling back to <NAME>_FILE when enabled.
+func (d *decoder) lookupNames(tag Tag, path string) (string, string, string, bool, error) {
+	for _, env := range tag.Names() {
+		// from=file reads the value from the file named by env_FILE only
+		if tag.From != SourceFile {
+			value, source, ok, err := d.opts.lookupFrom(d.ctx, env, tag.From)
 			if err != nil {
-				return fmt.Errorf("error parsing slice field '%s' : %w", tField.Name, err)
+				return env, "", "", false, LookupError{Env: env, Field: path, Err: err}
+			}
+			if ok {
+				return env, value, source, true, nil
 			}
-			vField.Set(reflect.ValueOf(values))
+		}
 
-		case reflect.String:
-			vField.SetString(value)
+		if !d.opts.FileFallback && tag.From != SourceFile {
+			continue
+		}
+
+		name, _, _, err := d.opts.lookup(d.ctx, env+FileSuffix)
+		if err != nil {
+			return env, "", "", false, LookupError{Env: env + FileSuffix, Field: path, Err: err}
+		}
 
-		case reflect.Int:
-			parsed, err := strconv.ParseInt(value, 10, 64)
+		if name != "" {
+			content, err := d.opts.readFile(name)
 			if err != nil {
-				return fmt.Errorf("error parsing int field '%s' : %w", tField.Name, err)
+				return env, "", "", false, ParseError{Env: env + FileSuffix, Field: path, Err: err}
 			}
-			vField.SetInt(parsed)
+			return env, content, SourceFile, true, nil
+		}
+	}
+
+	return tag.Env, "", "", false, nil
+}
+
+// unknown reports the variables under the prefix that no field consumed.
+func (d *decoder) unknown() []error {
+	seen := map[string]bool{}
 
+	prefix, consumed := d.opts.Prefix, d.consumed
+	if d.opts.CaseInsensitive {
+		prefix, consumed = strings.ToUpper(prefix), map[string]bool{}
+		for key := range d.consumed {
+			consumed[strings.ToUpper(key)] = true
 		}
 	}
 
-	return nil
+	var names []string
+	for _, key := range d.opts.keys() {
+		name := key
+		if d.opts.CaseInsensitive {
+			name = strings.ToUpper(key)
+		}
+
+		if !strings.HasPrefix(name, prefix) || consumed[name] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, key)
+	}
+
+	sort.Strings(names)
+
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, UnknownError{Env: name})
+	}
+	return errs
+}
+
+func (o Options) optional(tag Tag) bool {
+	return tag.Optional || (o.DefaultOptional && !tag.Required)
+}
+
+func (d *decoder) expand(value string) (string, error) {
+	var errs []error
+
+	expanded := os.Expand(value, func(key string) string {
+		value, _, _, err := d.opts.lookup(d.ctx, key)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return value
+	})
+
+	return expanded, errors.Join(errs...)
 }
 
-func parseSlice(value string, elemType reflect.Type) (interface{}, error) {
-	switch elemType.Kind() {
+// expandDefault replaces ${NAME} in a default with the value of an earlier
+// field, trying NAME with the current prefix first, or else the variable
+// NAME. Other uses of $ are left as they are.
+func (d *decoder) expandDefault(value, prefix string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference in default '%s'", value)
+		}
+		end += start
+
+		b.WriteString(value[:start])
+
+		resolved, err := d.reference(value[start+2:end], prefix)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(resolved)
+
+		value = value[end+1:]
+	}
+}
+
+// reference resolves a variable named by another field's tag, preferring the
+// value resolved for a field in the same prefix, then any field, and then the
+// variable itself.
+func (d *decoder) reference(name, prefix string) (string, error) {
+	if resolved, ok := d.resolved[d.opts.rename(prefix+name)]; ok {
+		return resolved, nil
+	}
+	if resolved, ok := d.resolved[d.opts.rename(name)]; ok {
+		return resolved, nil
+	}
+
+	value, _, _, err := d.opts.lookup(d.ctx, d.opts.rename(name))
+	return value, err
+}
+
+func (d *decoder) setField(v reflect.Value, value string, tag Tag) error {
+	if tag.JSON {
+		ptr := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+			return err
+		}
+		v.Set(ptr.Elem())
+		return nil
+	}
+
+	if fn, ok := d.opts.parser(v.Type()); ok {
+		return callParser(fn, v, value)
+	}
+
+	// plain strings have no methods, skip the checks below
+	if v.Type() == stringType {
+		v.SetString(value)
+		return nil
+	}
+
+	if ok, err := setEnv(v, value); ok {
+		return err
+	}
+
+	if v.Type() == durationType {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(parsed))
+		return nil
+	}
+
+	if tag.Layout != "" && (v.Type() == timeType || v.Type() == reflect.PtrTo(timeType)) {
+		parsed, err := time.Parse(timeLayout(tag.Layout), value)
+		if err != nil {
+			return err
+		}
+
+		if v.Kind() == reflect.Ptr {
+			v.Set(reflect.ValueOf(&parsed))
+		} else {
+			v.Set(reflect.ValueOf(parsed))
+		}
+		return nil
+	}
+
+	if ok, err := unmarshalText(v, value); ok {
+		return err
+	}
+
+	if tag.Unit == UnitBytes && v.Kind() != reflect.Ptr {
+		return setBytes(v, value)
+	}
+
+	if isByteSlice(v.Type()) {
+		decoded, err := decodeBytes(value, tag.Encoding)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(decoded)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Type().Elem() == v.Type() {
+			// type P *P would be allocated without end
+			return ErrUnsupported
+		}
+		ptr := reflect.New(v.Type().Elem())
+		if err := d.setField(ptr.Elem(), value, tag); err != nil {
+			return err
+		}
+		v.Set(ptr)
+
+	case reflect.Slice:
+		return d.setSlice(v, value, tag)
+
+	case reflect.Array:
+		return d.setArray(v, value, tag)
+
+	case reflect.Map:
+		return d.setMap(v, value, tag)
+
 	case reflect.String:
-		return strings.Split(value, ","), nil
+		v.SetString(value)
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(parsed)
+
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return rangeError(v, value, err)
+		}
+		v.SetFloat(parsed)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return rangeError(v, value, err)
+		}
+		v.SetInt(parsed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return rangeError(v, value, err)
+		}
+		v.SetUint(parsed)
+
+	default:
+		return ErrUnsupported
+	}
+
+	return nil
+}
+
+func unmarshalText(v reflect.Value, value string) (bool, error) {
+	if v.Kind() == reflect.Ptr && v.Type().Implements(textUnmarshalerType) {
+		ptr := reflect.New(v.Type().Elem())
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return true, err
+		}
+		v.Set(ptr)
+		return true, nil
+	}
 
-	case reflect.Int:
-		values := strings.Split(value, ",")
-		res := make([]int, len(values))
-		for i, v := range values {
-			var err error
-			if res[i], err = strconv.Atoi(v); err != nil {
-				return nil, fmt.Errorf("error parsing int slice : %w", err)
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return true, v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	return false, nil
+}
+
+func (o Options) separator(tag Tag) string {
+	if tag.PathList {
+		return string(os.PathListSeparator)
+	}
+
+	if tag.Separator != "" {
+		return tag.Separator
+	}
+
+	if o.Separator != "" {
+		return o.Separator
+	}
+
+	return ","
+}
+
+// splitList splits the value of a slice or array, cleaning the elements of
+// path lists as the tag asks.
+func (o Options) splitList(value string, tag Tag) ([]string, error) {
+	values, err := SplitList(value, o.separator(tag), tag.NoTrim)
+	if err != nil || !tag.PathList || tag.PathMode == "" {
+		return values, err
+	}
+
+	for i, path := range values {
+		path = filepath.Clean(path)
+		if tag.PathMode == PathAbs {
+			if path, err = filepath.Abs(path); err != nil {
+				return nil, fmt.Errorf("error resolving path '%s' : %w", values[i], err)
+			}
+		}
+		values[i] = path
+	}
+
+	return values, nil
+}
+
+// setArray sets a fixed length array, which needs exactly as many elements,
+// or bytes for byte arrays, as its length.
+func (d *decoder) setArray(v reflect.Value, value string, tag Tag) error {
+	if isByteArray(v.Type()) {
+		decoded, err := decodeBytes(value, tag.Encoding)
+		if err != nil {
+			return err
+		}
+		if len(decoded) != v.Len() {
+			return fmt.Errorf("expected %d bytes, found %d", v.Len(), len(decoded))
+		}
+		reflect.Copy(v, reflect.ValueOf(decoded))
+		return nil
+	}
+
+	values, err := d.opts.splitList(value, tag)
+	if err != nil {
+		return err
+	}
+	if len(values) != v.Len() {
+		return fmt.Errorf("expected %d elements, found %d", v.Len(), len(values))
+	}
+
+	array := reflect.New(v.Type()).Elem()
+	for i, value := range values {
+		if err := d.setField(array.Index(i), value, tag); err != nil {
+			if errors.Is(err, ErrUnsupported) {
+				return err
 			}
+			return fmt.Errorf("error parsing array element %d : %w", i, err)
 		}
-		return res, nil
 	}
 
-	return nil, fmt.Errorf("unsupported slice type '%s'", elemType.Kind())
+	v.Set(array)
+	return nil
 }
 
-type Tag struct {
-	Env      string
-	Optional bool
-	Default  string
+func (d *decoder) setSlice(v reflect.Value, value string, tag Tag) error {
+	values, err := d.opts.splitList(value, tag)
+	if err != nil {
+		return err
+	}
+
+	slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := d.setField(slice.Index(i), value, tag); err != nil {
+			if errors.Is(err, ErrUnsupported) {
+				return err
+			}
+			return fmt.Errorf("error parsing slice element %d : %w", i, err)
+		}
+	}
+
+	v.Set(slice)
+	return nil
 }
 
-func parseTag(tag reflect.StructTag) (Tag, bool, error) {
-	raw, ok := tag.Lookup(TagName)
-	if !ok {
-		return Tag{}, false, nil
+func (d *decoder) setMap(v reflect.Value, value string, tag Tag) error {
+	kvSep := tag.KeyValueSeparator
+	if kvSep == "" {
+		kvSep = "="
 	}
 
-	parts := strings.Split(raw, ",")
-	if len(parts) == 0 {
-		return Tag{}, false, fmt.Errorf("empty tag '%s'", TagName)
+	pairs, err := SplitList(value, d.opts.separator(tag), tag.NoTrim)
+	if err != nil {
+		return err
 	}
 
-	var opt bool
-	var def string
-	for _, value := range parts[1:] {
-		switch {
-		case value == "optional":
-			opt = true
+	m := reflect.MakeMapWithSize(v.Type(), len(pairs))
+	for _, pair := range pairs {
+		rawKey, rawValue, ok := strings.Cut(pair, kvSep)
+		if !ok {
+			return fmt.Errorf("invalid map entry '%s', expected 'key%svalue'", pair, kvSep)
+		}
+
+		if !tag.NoTrim {
+			rawKey, rawValue = strings.TrimSpace(rawKey), strings.TrimSpace(rawValue)
+		}
 
-		case strings.HasPrefix(value, "default"):
-			defParts := strings.SplitN(value, "=", 2)
-			if len(defParts) != 2 {
-				return Tag{}, false, fmt.Errorf("invalid use of default in tag '%s', expected 'default=value', found '%s'", TagName, value)
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := d.setField(key, rawKey, tag); err != nil {
+			if errors.Is(err, ErrUnsupported) {
+				return err
 			}
-			def = defParts[1]
+			return fmt.Errorf("error parsing map key '%s' : %w", rawKey, err)
 		}
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := d.setField(elem, rawValue, tag); err != nil {
+			if errors.Is(err, ErrUnsupported) {
+				return err
+			}
+			return fmt.Errorf("error parsing map value for key '%s' : %w", rawKey, err)
+		}
+
+		m.SetMapIndex(key, elem)
 	}
 
-	return Tag{
-		Env:      parts[0],
-		Optional: opt,
-		Default:  def,
-	}, true, nil
+	v.Set(m)
+	return nil
 }
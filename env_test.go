@@ -0,0 +1,1594 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// unsetenv removes keys from the process environment for the duration of
+// the test.
+func unsetenv(t *testing.T, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		t.Setenv(key, "")
+		if err := os.Unsetenv(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// setValue parses value into a new value of type t as Parse would for a
+// field without options.
+func setValue(t reflect.Type, value string, opts ...Option) (interface{}, error) {
+	d := newDecoder(context.Background(), newOptions(opts))
+
+	v := reflect.New(t).Elem()
+	err := d.setField(v, value, Tag{})
+	return v.Interface(), err
+}
+
+type setValueTest struct {
+	value string
+	want  interface{}
+	err   bool
+}
+
+func testSetValue(t *testing.T, typ reflect.Type, tests []setValueTest, opts ...Option) {
+	t.Helper()
+
+	for _, tt := range tests {
+		got, err := setValue(typ, tt.value, opts...)
+		if (err != nil) != tt.err {
+			t.Errorf("unexpected error for %s %q : %v", typ, tt.value, err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expected %s %q to be %#v, found %#v", typ, tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestExportResolved(t *testing.T) {
+	type config struct {
+		Host    string `env:"EXPORT_HOST,default=localhost"`
+		Port    string `env:"EXPORT_PORT,optional"`
+		Region  string `env:"EXPORT_REGION,optional"`
+		Missing string `env:"EXPORT_MISSING,optional"`
+	}
+
+	tests := []struct {
+		name     string
+		environ  map[string]string
+		opts     []Option
+		exported map[string]string
+	}{
+		{
+			name:     "defaults",
+			exported: map[string]string{"EXPORT_HOST": "localhost"},
+		},
+		{
+			name:     "other sources",
+			opts:     []Option{WithVars(map[string]string{"EXPORT_PORT": "8080"})},
+			exported: map[string]string{"EXPORT_HOST": "localhost", "EXPORT_PORT": "8080"},
+		},
+		{
+			name:     "process values are kept",
+			environ:  map[string]string{"EXPORT_REGION": "eu"},
+			exported: map[string]string{"EXPORT_HOST": "localhost", "EXPORT_REGION": "eu"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "EXPORT_HOST", "EXPORT_PORT", "EXPORT_REGION", "EXPORT_MISSING")
+			for key, value := range tt.environ {
+				t.Setenv(key, value)
+			}
+
+			var cfg config
+			if err := ParseWithOptions(&cfg, append(tt.opts, WithExportResolved())...); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range []string{"EXPORT_HOST", "EXPORT_PORT", "EXPORT_REGION", "EXPORT_MISSING"} {
+				value, ok := os.LookupEnv(key)
+				want, exported := tt.exported[key]
+				if ok != exported || value != want {
+					t.Errorf("expected %s=%q (set %v), found %q (set %v)", key, want, exported, value, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestExportResolvedHermetic(t *testing.T) {
+	var cfg struct {
+		Host string `env:"HOST,default=localhost"`
+	}
+	if err := ParseWithOptions(&cfg, WithExportResolved(), WithHermetic()); err == nil {
+		t.Error("expected export to be rejected in hermetic mode")
+	}
+}
+
+func TestExportResolvedFailedParse(t *testing.T) {
+	unsetenv(t, "EXPORT_HOST", "EXPORT_PORT")
+
+	var cfg struct {
+		Host string `env:"EXPORT_HOST,default=localhost"`
+		Port int    `env:"EXPORT_PORT"`
+	}
+	if err := ParseWithOptions(&cfg, WithExportResolved()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if value, ok := os.LookupEnv("EXPORT_HOST"); ok {
+		t.Errorf("expected nothing to be exported, found EXPORT_HOST=%q", value)
+	}
+}
+
+func TestHermetic(t *testing.T) {
+	type config struct {
+		Host string `env:"HERMETIC_HOST,optional"`
+		Key  string `env:"HERMETIC_KEY,file,optional"`
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want config
+		err  bool
+	}{
+		{
+			name: "ignores the process environment",
+			opts: []Option{WithHermetic()},
+		},
+		{
+			name: "reads vars and lookupers",
+			opts: []Option{WithHermetic(), WithVars(map[string]string{"HERMETIC_HOST": "vars"})},
+			want: config{Host: "vars"},
+		},
+		{
+			name: "reads files from the FS",
+			opts: []Option{WithHermetic(), WithLookuper(Map{"HERMETIC_KEY": "/key"}), WithFS(fstest.MapFS{"key": {Data: []byte("secret\n")}})},
+			want: config{Key: "secret"},
+		},
+		{
+			name: "rejects files without a FS",
+			opts: []Option{WithHermetic(), WithLookuper(Map{"HERMETIC_KEY": "/etc/hostname"})},
+			err:  true,
+		},
+		{
+			name: "rejects dotenv files without a FS",
+			opts: []Option{WithHermetic(), WithDotenv(".env")},
+			err:  true,
+		},
+		{
+			name: "reads dotenv files from the FS",
+			opts: []Option{WithHermetic(), WithDotenv(".env"), WithFS(fstest.MapFS{".env": {Data: []byte("HERMETIC_HOST=dotenv\n")}})},
+			want: config{Host: "dotenv"},
+		},
+		{
+			name: "without hermetic",
+			want: config{Host: "process"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HERMETIC_HOST", "process")
+
+			var cfg config
+			err := ParseWithOptions(&cfg, tt.opts...)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !tt.err && cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestDurationFields(t *testing.T) {
+	testSetValue(t, reflect.TypeOf(time.Duration(0)), []setValueTest{
+		{value: "30s", want: 30 * time.Second},
+		{value: "1h30m", want: 90 * time.Minute},
+		{value: "-1.5ms", want: -1500 * time.Microsecond},
+		{value: "0", want: time.Duration(0)},
+		{value: "30", err: true},
+		{value: "soon", err: true},
+	})
+
+	testSetValue(t, reflect.TypeOf((*time.Duration)(nil)), []setValueTest{
+		{value: "1m", want: func() *time.Duration { d := time.Minute; return &d }()},
+	})
+}
+
+func TestIntegerFields(t *testing.T) {
+	tests := []struct {
+		typ   reflect.Type
+		tests []setValueTest
+	}{
+		{reflect.TypeOf(uint(0)), []setValueTest{{value: "42", want: uint(42)}, {value: "-1", err: true}, {value: "1.5", err: true}}},
+		{reflect.TypeOf(uint8(0)), []setValueTest{{value: "255", want: uint8(255)}, {value: "256", err: true}}},
+		{reflect.TypeOf(uint16(0)), []setValueTest{{value: "65535", want: uint16(65535)}, {value: "65536", err: true}}},
+		{reflect.TypeOf(uint32(0)), []setValueTest{{value: "4294967295", want: uint32(4294967295)}, {value: "4294967296", err: true}}},
+		{reflect.TypeOf(uint64(0)), []setValueTest{{value: "18446744073709551615", want: uint64(18446744073709551615)}, {value: "18446744073709551616", err: true}}},
+		{reflect.TypeOf(int8(0)), []setValueTest{{value: "-128", want: int8(-128)}, {value: "127", want: int8(127)}, {value: "128", err: true}, {value: "-129", err: true}}},
+		{reflect.TypeOf(int16(0)), []setValueTest{{value: "32767", want: int16(32767)}, {value: "32768", err: true}}},
+		{reflect.TypeOf(int32(0)), []setValueTest{{value: "-2147483648", want: int32(-2147483648)}, {value: "2147483648", err: true}}},
+		{reflect.TypeOf(int64(0)), []setValueTest{{value: "-9223372036854775808", want: int64(-9223372036854775808)}, {value: "9223372036854775808", err: true}}},
+		{reflect.TypeOf(float32(0)), []setValueTest{{value: "1.5", want: float32(1.5)}, {value: "1e39", err: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			testSetValue(t, tt.typ, tt.tests)
+		})
+	}
+}
+
+func TestIntegerRangeErrors(t *testing.T) {
+	tests := []struct {
+		typ   reflect.Type
+		value string
+		want  string
+	}{
+		{reflect.TypeOf(uint8(0)), "256", "value '256' out of range for type 'uint8', expected 0 to 255 : value out of range"},
+		{reflect.TypeOf(uint16(0)), "-1", "value '-1' out of range for type 'uint16', expected 0 to 65535 : value out of range"},
+		{reflect.TypeOf(int8(0)), "200", "value '200' out of range for type 'int8', expected -128 to 127 : value out of range"},
+	}
+
+	for _, tt := range tests {
+		_, err := setValue(tt.typ, tt.value)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("expected %q, found %v", tt.want, err)
+		}
+		if !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("expected %v to wrap strconv.ErrRange", err)
+		}
+	}
+}
+
+type textLevel int
+
+func (l *textLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+func TestTextUnmarshalerFields(t *testing.T) {
+	testSetValue(t, reflect.TypeOf(textLevel(0)), []setValueTest{
+		{value: "info", want: textLevel(1)},
+		{value: "trace", err: true},
+	})
+	testSetValue(t, reflect.TypeOf((*textLevel)(nil)), []setValueTest{
+		{value: "info", want: func() *textLevel { l := textLevel(1); return &l }()},
+	})
+	testSetValue(t, reflect.TypeOf(net.IP{}), []setValueTest{
+		{value: "10.0.0.1", want: net.ParseIP("10.0.0.1")},
+		{value: "10.0.0", err: true},
+	})
+
+	var cfg struct {
+		Level textLevel `env:"LEVEL"`
+	}
+	err := ParseWithOptions(&cfg, WithLookuper(Map{"LEVEL": "trace"}), WithHermetic())
+	var parseErr ParseError
+	if !errors.As(err, &parseErr) || parseErr.Env != "LEVEL" {
+		t.Errorf("expected a ParseError for LEVEL, found %v", err)
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=5432"`
+	}
+	type cache struct {
+		DB database `envPrefix:"DB_"`
+	}
+	type embedded struct {
+		Region string `env:"REGION,optional"`
+	}
+	type config struct {
+		embedded
+		Primary database  `envPrefix:"DB_"`
+		Replica *database `envPrefix:"REPLICA_"`
+		Cache   cache     `envPrefix:"CACHE_"`
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		vars Map
+		want config
+	}{
+		{
+			name: "nested prefixes",
+			vars: Map{"DB_HOST": "primary", "REPLICA_HOST": "replica", "REPLICA_PORT": "5433", "CACHE_DB_HOST": "cache", "REGION": "eu"},
+			want: config{
+				embedded: embedded{Region: "eu"},
+				Primary:  database{Host: "primary", Port: 5432},
+				Replica:  &database{Host: "replica", Port: 5433},
+				Cache:    cache{DB: database{Host: "cache", Port: 5432}},
+			},
+		},
+		{
+			name: "global prefix",
+			opts: []Option{WithPrefix("APP_")},
+			vars: Map{"APP_DB_HOST": "primary", "APP_CACHE_DB_HOST": "cache", "DB_HOST": "ignored"},
+			want: config{
+				Primary: database{Host: "primary", Port: 5432},
+				Cache:   cache{DB: database{Host: "cache", Port: 5432}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	type config struct {
+		Host  string   `env:"HOST,default=localhost"`
+		Tags  []string `env:"TAGS,optional"`
+		Token string   `env:"TOKEN"`
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		opts []Option
+		want config
+		err  bool
+	}{
+		{
+			name: "no options reads the environment",
+			env:  map[string]string{"HOST": "env", "TOKEN": "t"},
+			want: config{Host: "env", Token: "t"},
+		},
+		{
+			name: "vars override the environment",
+			env:  map[string]string{"HOST": "env", "TOKEN": "t"},
+			opts: []Option{WithVars(map[string]string{"HOST": "vars"})},
+			want: config{Host: "vars", Token: "t"},
+		},
+		{
+			name: "separator",
+			env:  map[string]string{"TAGS": "a;b", "TOKEN": "t"},
+			opts: []Option{WithSeparator(";")},
+			want: config{Host: "localhost", Tags: []string{"a", "b"}, Token: "t"},
+		},
+		{
+			name: "missing required",
+			err:  true,
+		},
+		{
+			name: "default optional",
+			opts: []Option{WithDefaultOptional()},
+			want: config{Host: "localhost"},
+		},
+		{
+			name: "empty is missing",
+			env:  map[string]string{"HOST": "", "TOKEN": ""},
+			err:  true,
+		},
+		{
+			name: "allow empty",
+			env:  map[string]string{"HOST": "", "TOKEN": ""},
+			opts: []Option{WithAllowEmpty()},
+			want: config{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "HOST", "TAGS", "TOKEN")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var cfg config
+			err := ParseWithOptions(&cfg, tt.opts...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+
+			if len(tt.opts) == 0 {
+				var plain config
+				if perr := Parse(&plain); (perr != nil) != tt.err || !reflect.DeepEqual(plain, cfg) {
+					t.Errorf("Parse differs from ParseWithOptions: %+v, %v", plain, perr)
+				}
+			}
+		})
+	}
+}
+
+func TestLookuper(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=80"`
+	}
+
+	tests := []struct {
+		name string
+		l    Lookuper
+		want config
+		err  bool
+	}{
+		{
+			name: "map",
+			l:    Map{"HOST": "map", "PORT": "8080"},
+			want: config{Host: "map", Port: 8080},
+		},
+		{
+			name: "func",
+			l: LookupFunc(func(key string) (string, bool) {
+				return strings.ToLower(key), key == "HOST"
+			}),
+			want: config{Host: "host", Port: 80},
+		},
+		{
+			name: "named",
+			l:    Named("test", Map{"HOST": "named"}),
+			want: config{Host: "named", Port: 80},
+		},
+		{
+			name: "missing",
+			l:    Map{"PORT": "8080"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the lookuper replaces the environment entirely
+			t.Setenv("HOST", "env")
+
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.l))
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestParseFromMap(t *testing.T) {
+	type config struct {
+		Name  string `env:"NAME"`
+		Debug bool   `env:"DEBUG,optional"`
+	}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want config
+		err  bool
+	}{
+		{name: "all set", vars: map[string]string{"NAME": "a", "DEBUG": "true"}, want: config{Name: "a", Debug: true}},
+		{name: "optional unset", vars: map[string]string{"NAME": "b"}, want: config{Name: "b"}},
+		{name: "required unset", vars: map[string]string{"DEBUG": "true"}, err: true},
+		{name: "nil map", err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// parsing from a map never reads the environment, so tests with
+			// different values can run in parallel
+			t.Parallel()
+
+			var cfg config
+			err := ParseFromMap(&cfg, tt.vars)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+		Name string `env:"NAME,optional"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want []string
+	}{
+		{
+			name: "valid",
+			vars: Map{"HOST": "h", "PORT": "80"},
+		},
+		{
+			name: "every problem listed",
+			vars: Map{"PORT": "eighty"},
+			want: []string{"env: invalid configuration", "\n  - ", "HOST", "PORT", "eighty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if tt.want == nil {
+					if r != nil {
+						t.Fatalf("unexpected panic: %v", r)
+					}
+					return
+				}
+
+				msg, ok := r.(string)
+				if !ok {
+					t.Fatalf("expected a string panic, found %v", r)
+				}
+				if n := strings.Count(msg, "\n  - "); n != 2 {
+					t.Errorf("expected 2 problems, found %d in %q", n, msg)
+				}
+				for _, want := range tt.want {
+					if !strings.Contains(msg, want) {
+						t.Errorf("expected %q in %q", want, msg)
+					}
+				}
+			}()
+
+			var cfg config
+			MustParse(&cfg, WithLookuper(tt.vars), WithHermetic())
+		})
+	}
+}
+
+func TestSeparators(t *testing.T) {
+	type config struct {
+		Hosts []string `env:"HOSTS,optional,separator=;"`
+		Names []string `env:"NAMES,optional,sep=|"`
+		Ports []int    `env:"PORTS,optional"`
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		vars Map
+		want config
+	}{
+		{
+			name: "tag separators",
+			vars: Map{"HOSTS": "a,1;b,2", "NAMES": "x, y|z", "PORTS": "80,443"},
+			want: config{Hosts: []string{"a,1", "b,2"}, Names: []string{"x, y", "z"}, Ports: []int{80, 443}},
+		},
+		{
+			name: "global separator",
+			opts: []Option{WithSeparator(" ")},
+			vars: Map{"HOSTS": "a;b", "PORTS": "80 443"},
+			want: config{Hosts: []string{"a", "b"}, Ports: []int{80, 443}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestSliceFields(t *testing.T) {
+	tests := []struct {
+		typ   reflect.Type
+		tests []setValueTest
+	}{
+		{reflect.TypeOf([]string(nil)), []setValueTest{{value: "a,b", want: []string{"a", "b"}}}},
+		{reflect.TypeOf([]bool(nil)), []setValueTest{{value: "true,false", want: []bool{true, false}}, {value: "true,maybe", err: true}}},
+		{reflect.TypeOf([]float64(nil)), []setValueTest{{value: "1.5,-2", want: []float64{1.5, -2}}, {value: "1,x", err: true}}},
+		{reflect.TypeOf([]int64(nil)), []setValueTest{{value: "-1,9223372036854775807", want: []int64{-1, 9223372036854775807}}}},
+		{reflect.TypeOf([]uint(nil)), []setValueTest{{value: "1,2", want: []uint{1, 2}}, {value: "1,-2", err: true}}},
+		{reflect.TypeOf([]time.Duration(nil)), []setValueTest{{value: "1s,2m", want: []time.Duration{time.Second, 2 * time.Minute}}, {value: "1s,2", err: true}}},
+		{reflect.TypeOf([]textLevel(nil)), []setValueTest{{value: "debug,info", want: []textLevel{0, 1}}, {value: "debug,loud", err: true}}},
+		{reflect.TypeOf([]*int(nil)), []setValueTest{{value: "1", want: []*int{func() *int { n := 1; return &n }()}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			testSetValue(t, tt.typ, tt.tests)
+		})
+	}
+}
+
+func TestMapFields(t *testing.T) {
+	type config struct {
+		Flags  map[string]bool   `env:"FLAGS,optional"`
+		Limits map[string]int    `env:"LIMITS,optional,separator=;,kvSeparator=:"`
+		Labels map[string]string `env:"LABELS,optional"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want config
+		err  bool
+	}{
+		{
+			name: "default separators",
+			vars: Map{"FLAGS": "search=true,beta=false", "LABELS": "app=web"},
+			want: config{Flags: map[string]bool{"search": true, "beta": false}, Labels: map[string]string{"app": "web"}},
+		},
+		{
+			name: "tag separators",
+			vars: Map{"LIMITS": "cpu:2;mem:512"},
+			want: config{Limits: map[string]int{"cpu": 2, "mem": 512}},
+		},
+		{
+			name: "invalid value",
+			vars: Map{"FLAGS": "search=maybe"},
+			err:  true,
+		},
+		{
+			name: "missing key value separator",
+			vars: Map{"LABELS": "app"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic())
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestPointerFields(t *testing.T) {
+	type config struct {
+		Port  *int    `env:"PORT,optional"`
+		Name  *string `env:"NAME,optional"`
+		Debug *bool   `env:"DEBUG,optional"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want config
+		err  bool
+	}{
+		{
+			name: "unset stays nil",
+			vars: Map{},
+		},
+		{
+			name: "zero values are set",
+			vars: Map{"PORT": "0", "DEBUG": "false"},
+			want: config{Port: new(int), Debug: new(bool)},
+		},
+		{
+			name: "values",
+			vars: Map{"PORT": "8080", "NAME": "app", "DEBUG": "true"},
+			want: func() config {
+				port, name, debug := 8080, "app", true
+				return config{Port: &port, Name: &name, Debug: &debug}
+			}(),
+		},
+		{
+			name: "invalid",
+			vars: Map{"PORT": "eighty"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic())
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestStructPointerFields(t *testing.T) {
+	type database struct {
+		Host string `env:"HOST,optional"`
+		Port int    `env:"PORT,default=5432"`
+	}
+	type config struct {
+		DB *database `envPrefix:"DB_"`
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		vars Map
+		want config
+	}{
+		{
+			name: "nil when unset",
+			vars: Map{},
+		},
+		{
+			name: "allocated when a variable is set",
+			vars: Map{"DB_HOST": "db"},
+			want: config{DB: &database{Host: "db", Port: 5432}},
+		},
+		{
+			name: "always allocated",
+			opts: []Option{WithAllocateStructs()},
+			vars: Map{},
+			want: config{DB: &database{Port: 5432}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+
+	t.Run("existing pointer is kept", func(t *testing.T) {
+		db := &database{Host: "old"}
+		cfg := config{DB: db}
+		if err := ParseFromMap(&cfg, map[string]string{"DB_HOST": "new"}); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.DB != db || db.Host != "new" {
+			t.Errorf("expected the existing struct to be updated, found %+v", cfg.DB)
+		}
+	})
+}
+
+type recursiveNode struct {
+	Name     string                   `env:"NAME,optional"`
+	Next     *recursiveNode           `envPrefix:"NEXT_"`
+	Children []recursiveNode          `envPrefix:"CHILD_"`
+	Named    map[string]recursiveNode `envPrefix:"NAMED_"`
+}
+
+type recursivePointer *recursivePointer
+
+func TestRecursiveTypes(t *testing.T) {
+	vars := Map{"NAME": "root", "NEXT_NAME": "next", "CHILD_0_NAME": "child", "NAMED_A_NAME": "a"}
+
+	t.Run("parse", func(t *testing.T) {
+		var node recursiveNode
+		if err := ParseWithOptions(&node, WithLookuper(vars), WithHermetic(), WithAllocateStructs()); err != nil {
+			t.Fatal(err)
+		}
+
+		// nil pointers and slices of an enclosing type are not descended
+		// into, maps only hold the keys that are set
+		want := recursiveNode{Name: "root", Named: map[string]recursiveNode{"A": {Name: "a"}}}
+		if !reflect.DeepEqual(node, want) {
+			t.Errorf("expected %+v, found %+v", want, node)
+		}
+	})
+
+	t.Run("existing pointers", func(t *testing.T) {
+		node := recursiveNode{Next: &recursiveNode{}}
+		if err := ParseFromMap(&node, vars); err != nil {
+			t.Fatal(err)
+		}
+		if node.Next.Name != "next" || node.Next.Next != nil {
+			t.Errorf("expected the existing node to be parsed, found %+v", node.Next)
+		}
+	})
+
+	t.Run("compile", func(t *testing.T) {
+		if _, err := Compile[recursiveNode](); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("describe", func(t *testing.T) {
+		specs, err := Describe(&recursiveNode{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(specs) != 1 || specs[0].Name != "NAME" {
+			t.Errorf("expected only NAME, found %+v", specs)
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		if _, _, err := Check(&recursiveNode{}, WithLookuper(vars), WithHermetic()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("pointer to itself", func(t *testing.T) {
+		var cfg struct {
+			P recursivePointer `env:"P"`
+		}
+		err := ParseFromMap(&cfg, map[string]string{"P": "x"})
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("expected ErrUnsupported, found %v", err)
+		}
+	})
+}
+
+func TestSkippedFields(t *testing.T) {
+	type nested struct {
+		Host string `env:"HOST"`
+	}
+	type config struct {
+		Name    string  `env:"NAME"`
+		Skipped string  `env:"-"`
+		Nested  nested  `env:"-"`
+		Pointer *nested `env:"-"`
+	}
+
+	// HOST is required inside the skipped structs, so parsing would fail if
+	// they were recursed into
+	var cfg config
+	cfg.Skipped = "kept"
+	if err := ParseFromMap(&cfg, map[string]string{"NAME": "app", "-": "ignored"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := config{Name: "app", Skipped: "kept"}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("expected %+v, found %+v", want, cfg)
+	}
+}
+
+func TestEmptyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  reflect.StructTag
+		opts []Option
+		vars Map
+		want string
+		err  bool
+	}{
+		{name: "empty required is missing", tag: `env:"V"`, vars: Map{"V": ""}, err: true},
+		{name: "empty uses default", tag: `env:"V,default=d"`, vars: Map{"V": ""}, want: "d"},
+		{name: "empty optional", tag: `env:"V,optional"`, vars: Map{"V": ""}},
+		{name: "allowEmpty satisfies required", tag: `env:"V,allowEmpty"`, vars: Map{"V": ""}},
+		{name: "allowEmpty suppresses default", tag: `env:"V,allowEmpty,default=d"`, vars: Map{"V": ""}},
+		{name: "allowEmpty unset uses default", tag: `env:"V,allowEmpty,default=d"`, vars: Map{}, want: "d"},
+		{name: "global allowEmpty", tag: `env:"V,default=d"`, opts: []Option{WithAllowEmpty()}, vars: Map{"V": ""}},
+		{name: "notEmpty rejects empty", tag: `env:"V,optional,notEmpty"`, vars: Map{"V": ""}, err: true},
+		{name: "notEmpty overrides allowEmpty", tag: `env:"V,notEmpty"`, opts: []Option{WithAllowEmpty()}, vars: Map{"V": ""}, err: true},
+		{name: "notEmpty unset optional", tag: `env:"V,optional,notEmpty"`, vars: Map{}},
+		{name: "notEmpty set", tag: `env:"V,notEmpty"`, vars: Map{"V": "v"}, want: "v"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(""), Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			err := ParseWithOptions(v.Interface(), append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if got := v.Elem().Field(0).String(); err == nil && got != tt.want {
+				t.Errorf("expected '%s', found '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  reflect.StructTag
+		vars Map
+		want string
+	}{
+		{name: "braces", tag: `env:"V,expand"`, vars: Map{"V": "${HOME}/logs/app.log", "HOME": "/home/app"}, want: "/home/app/logs/app.log"},
+		{name: "bare", tag: `env:"V,expand"`, vars: Map{"V": "$HOME/logs", "HOME": "/home/app"}, want: "/home/app/logs"},
+		{name: "unset reference", tag: `env:"V,expand"`, vars: Map{"V": "${MISSING}/logs"}, want: "/logs"},
+		{name: "default", tag: `env:"V,expand,default=${HOME}/logs"`, vars: Map{"HOME": "/home/app"}, want: "/home/app/logs"},
+		{name: "not expanded without option", tag: `env:"V"`, vars: Map{"V": "$HOME/logs", "HOME": "/home/app"}, want: "$HOME/logs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(""), Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			if err := ParseFromMap(v.Interface(), tt.vars); err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Elem().Field(0).String(); got != tt.want {
+				t.Errorf("expected '%s', found '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultOptional(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      reflect.StructTag
+		optional bool
+		vars     Map
+		want     string
+		err      bool
+	}{
+		{name: "required by default", tag: `env:"V"`, err: true},
+		{name: "optional tag", tag: `env:"V,optional"`},
+		{name: "optional by default", tag: `env:"V"`, optional: true},
+		{name: "required tag", tag: `env:"V,required"`, optional: true, err: true},
+		{name: "required tag set", tag: `env:"V,required"`, optional: true, vars: Map{"V": "v"}, want: "v"},
+		{name: "default", tag: `env:"V,default=d"`, optional: true, want: "d"},
+		{name: "required conflicts with default", tag: `env:"V,required,default=d"`, optional: true, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(""), Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			opts := []Option{WithLookuper(tt.vars), WithHermetic()}
+			if tt.optional {
+				opts = append(opts, WithDefaultOptional())
+			}
+
+			err := ParseWithOptions(v.Interface(), opts...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if got := v.Elem().Field(0).String(); err == nil && got != tt.want {
+				t.Errorf("expected '%s', found '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAliases(t *testing.T) {
+	type config struct {
+		URL string `env:"DATABASE_URL|DB_URL|POSTGRES_URL"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want string
+	}{
+		{name: "name", vars: Map{"DATABASE_URL": "a", "DB_URL": "b", "POSTGRES_URL": "c"}, want: "a"},
+		{name: "first alias", vars: Map{"DB_URL": "b", "POSTGRES_URL": "c"}, want: "b"},
+		{name: "last alias", vars: Map{"POSTGRES_URL": "c"}, want: "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := ParseFromMap(&cfg, tt.vars); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.URL != tt.want {
+				t.Errorf("expected '%s', found '%s'", tt.want, cfg.URL)
+			}
+		})
+	}
+
+	t.Run("missing lists every name", func(t *testing.T) {
+		var cfg config
+		err := ParseFromMap(&cfg, nil)
+
+		var missing MissingError
+		if !errors.As(err, &missing) {
+			t.Fatalf("expected a MissingError, found %v", err)
+		}
+		if want := []string{"DB_URL", "POSTGRES_URL"}; missing.Env != "DATABASE_URL" || !reflect.DeepEqual(missing.Aliases, want) {
+			t.Errorf("expected DATABASE_URL and %v, found %s and %v", want, missing.Env, missing.Aliases)
+		}
+	})
+
+	t.Run("prefixed", func(t *testing.T) {
+		var cfg config
+		if err := ParseWithOptions(&cfg, WithLookuper(Map{"APP_POSTGRES_URL": "c", "POSTGRES_URL": "x"}), WithHermetic(), WithPrefix("APP_")); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.URL != "c" {
+			t.Errorf("expected 'c', found '%s'", cfg.URL)
+		}
+	})
+}
+
+func TestUnset(t *testing.T) {
+	type config struct {
+		Secret string `env:"UNSET_SECRET|UNSET_TOKEN,unset"`
+		Host   string `env:"UNSET_HOST"`
+		Port   string `env:"UNSET_PORT,optional"`
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		opts  []Option
+		unset []string
+		kept  []string
+		err   bool
+	}{
+		{
+			name:  "unset tag",
+			env:   map[string]string{"UNSET_SECRET": "s", "UNSET_HOST": "h"},
+			unset: []string{"UNSET_SECRET"},
+			kept:  []string{"UNSET_HOST"},
+		},
+		{
+			name:  "alias that was read",
+			env:   map[string]string{"UNSET_TOKEN": "s", "UNSET_HOST": "h"},
+			unset: []string{"UNSET_TOKEN"},
+			kept:  []string{"UNSET_HOST"},
+		},
+		{
+			name:  "unset after read",
+			env:   map[string]string{"UNSET_SECRET": "s", "UNSET_HOST": "h", "UNSET_PORT": "80"},
+			opts:  []Option{WithUnsetAfterRead()},
+			unset: []string{"UNSET_SECRET", "UNSET_HOST", "UNSET_PORT"},
+		},
+		{
+			name: "values from vars are left alone",
+			env:  map[string]string{"UNSET_SECRET": "s"},
+			opts: []Option{WithVars(map[string]string{"UNSET_SECRET": "vars", "UNSET_HOST": "h"})},
+			kept: []string{"UNSET_SECRET"},
+		},
+		{
+			name: "kept when parsing fails",
+			env:  map[string]string{"UNSET_SECRET": "s"},
+			kept: []string{"UNSET_SECRET"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "UNSET_SECRET", "UNSET_TOKEN", "UNSET_HOST", "UNSET_PORT")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var cfg config
+			err := ParseWithOptions(&cfg, tt.opts...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && cfg.Secret == "" {
+				t.Errorf("expected the value to be captured before unsetting")
+			}
+
+			for _, key := range tt.unset {
+				if _, ok := os.LookupEnv(key); ok {
+					t.Errorf("expected %s to be unset", key)
+				}
+			}
+			for _, key := range tt.kept {
+				if _, ok := os.LookupEnv(key); !ok {
+					t.Errorf("expected %s to be kept", key)
+				}
+			}
+		})
+	}
+}
+
+func TestStrict(t *testing.T) {
+	type config struct {
+		Port int    `env:"PORT,default=80"`
+		Host string `env:"HOST|HOSTNAME,optional"`
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		vars    Map
+		unknown []string
+		err     bool
+	}{
+		{
+			name: "no unknown variables",
+			opts: []Option{WithPrefix("MYAPP_"), WithStrict()},
+			vars: Map{"MYAPP_PORT": "8080", "MYAPP_HOSTNAME": "h", "OTHER": "x"},
+		},
+		{
+			name:    "typos are reported",
+			opts:    []Option{WithPrefix("MYAPP_"), WithStrict()},
+			vars:    Map{"MYAPP_PROT": "8080", "MYAPP_HOTS": "h", "OTHER_PROT": "x"},
+			unknown: []string{"MYAPP_HOTS", "MYAPP_PROT"},
+		},
+		{
+			name: "not strict",
+			opts: []Option{WithPrefix("MYAPP_")},
+			vars: Map{"MYAPP_PROT": "8080"},
+		},
+		{
+			name: "strict needs a prefix",
+			opts: []Option{WithStrict()},
+			vars: Map{"PROT": "8080"},
+			err:  true,
+		},
+		{
+			name:    "case insensitive",
+			opts:    []Option{WithPrefix("MYAPP_"), WithStrict(), WithCaseInsensitive()},
+			vars:    Map{"myapp_port": "8080", "myapp_prot": "8080"},
+			unknown: []string{"myapp_prot"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if tt.err {
+				if err == nil || !strings.Contains(err.Error(), "requires a prefix") {
+					t.Errorf("expected an error for the missing prefix, found %v", err)
+				}
+				return
+			}
+
+			var unknown []string
+			for _, e := range unwrapErrors(err) {
+				var u UnknownError
+				if !errors.As(e, &u) || !errors.Is(e, ErrUnknown) {
+					t.Fatalf("unexpected error %v", e)
+				}
+				unknown = append(unknown, u.Env)
+			}
+			if !reflect.DeepEqual(unknown, tt.unknown) {
+				t.Errorf("expected %v, found %v", tt.unknown, unknown)
+			}
+		})
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	type limit struct {
+		Name string `json:"name"`
+		Rate int    `json:"rate"`
+	}
+	type config struct {
+		Limits map[string]int    `env:"RATE_LIMITS,json,optional"`
+		Rules  []limit           `env:"RULES,json,optional"`
+		Nested *map[string][]int `env:"NESTED,json,optional"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want config
+		err  bool
+	}{
+		{
+			name: "map",
+			vars: Map{"RATE_LIMITS": `{"read":100,"write":20}`},
+			want: config{Limits: map[string]int{"read": 100, "write": 20}},
+		},
+		{
+			name: "slice of structs",
+			vars: Map{"RULES": `[{"name":"a","rate":1},{"name":"b,c","rate":2}]`},
+			want: config{Rules: []limit{{Name: "a", Rate: 1}, {Name: "b,c", Rate: 2}}},
+		},
+		{
+			name: "nested",
+			vars: Map{"NESTED": `{"a":[1,2]}`},
+			want: config{Nested: &map[string][]int{"a": {1, 2}}},
+		},
+		{
+			name: "invalid",
+			vars: Map{"RATE_LIMITS": `{"read":"many"}`},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseFromMap(&cfg, tt.vars)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+
+			// marshalling writes the fields back as JSON
+			vars, err := Marshal(&cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got config
+			if err := ParseFromMap(&got, vars); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, cfg) {
+				t.Errorf("expected %+v to round trip, found %+v", cfg, got)
+			}
+		})
+	}
+}
+
+func TestTimeSliceFields(t *testing.T) {
+	date := func(day int) time.Time { return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		tag  reflect.StructTag
+		typ  reflect.Type
+		vars Map
+		want interface{}
+		err  bool
+	}{
+		{name: "durations", tag: `env:"V"`, typ: reflect.TypeOf([]time.Duration(nil)), vars: Map{"V": "100ms,500ms,2s"}, want: []time.Duration{100 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second}},
+		{name: "invalid duration", tag: `env:"V"`, typ: reflect.TypeOf([]time.Duration(nil)), vars: Map{"V": "100ms,2"}, err: true},
+		{name: "times", tag: `env:"V"`, typ: reflect.TypeOf([]time.Time(nil)), vars: Map{"V": "2024-01-01T00:00:00Z,2024-01-02T00:00:00Z"}, want: []time.Time{date(1), date(2)}},
+		{name: "layout", tag: `env:"V,layout=2006-01-02"`, typ: reflect.TypeOf([]time.Time(nil)), vars: Map{"V": "2024-01-01,2024-01-03"}, want: []time.Time{date(1), date(3)}},
+		{name: "named layout with commas", tag: `env:"V,layout=RFC1123,separator=;"`, typ: reflect.TypeOf([]time.Time(nil)), vars: Map{"V": "Mon, 01 Jan 2024 00:00:00 UTC;Tue, 02 Jan 2024 00:00:00 UTC"}, want: []time.Time{date(1), date(2)}},
+		{name: "wrong layout", tag: `env:"V,layout=2006-01-02"`, typ: reflect.TypeOf([]time.Time(nil)), vars: Map{"V": "01/02/2024"}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: tt.typ, Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			err := ParseFromMap(v.Interface(), tt.vars)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := v.Elem().Field(0).Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, found %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultReferences(t *testing.T) {
+	type server struct {
+		Host string `env:"HOST,default=localhost"`
+		URL  string `env:"URL,default=http://${HOST}"`
+	}
+	type config struct {
+		Host      string `env:"HOST,default=example.com"`
+		Port      int    `env:"PORT,default=8443"`
+		PublicURL string `env:"PUBLIC_URL,default=https://${HOST}:${PORT}"`
+		Literal   string `env:"LITERAL,default=$HOME"`
+		Admin     server `envPrefix:"ADMIN_"`
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		vars Map
+		want config
+		err  bool
+	}{
+		{
+			name: "resolved fields",
+			want: config{Host: "example.com", Port: 8443, PublicURL: "https://example.com:8443", Literal: "$HOME", Admin: server{Host: "localhost", URL: "http://localhost"}},
+		},
+		{
+			name: "set variables",
+			vars: Map{"HOST": "h", "PORT": "1", "ADMIN_HOST": "admin"},
+			want: config{Host: "h", Port: 1, PublicURL: "https://h:1", Literal: "$HOME", Admin: server{Host: "admin", URL: "http://admin"}},
+		},
+		{
+			name: "set value wins over default",
+			vars: Map{"PUBLIC_URL": "https://${HOST}"},
+			want: config{Host: "example.com", Port: 8443, PublicURL: "https://${HOST}", Literal: "$HOME", Admin: server{Host: "localhost", URL: "http://localhost"}},
+		},
+		{
+			name: "prefixed",
+			opts: []Option{WithPrefix("APP_")},
+			vars: Map{"APP_HOST": "h"},
+			want: config{Host: "h", Port: 8443, PublicURL: "https://h:8443", Literal: "$HOME", Admin: server{Host: "localhost", URL: "http://localhost"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+
+	t.Run("unterminated", func(t *testing.T) {
+		var cfg struct {
+			URL string `env:"URL,default=${HOST"`
+		}
+		if err := ParseFromMap(&cfg, nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("variable without a field", func(t *testing.T) {
+		var cfg struct {
+			URL string `env:"URL,default=http://${OTHER}/"`
+		}
+		if err := ParseFromMap(&cfg, map[string]string{"OTHER": "o"}); err != nil || cfg.URL != "http://o/" {
+			t.Errorf("expected 'http://o/', found '%s', %v", cfg.URL, err)
+		}
+	})
+}
+
+func TestKeepExisting(t *testing.T) {
+	type config struct {
+		Port int    `env:"PORT"`
+		Host string `env:"HOST,keep"`
+		Name string `env:"NAME,default=app"`
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		init config
+		vars Map
+		want config
+		err  bool
+	}{
+		{
+			name: "keep tag",
+			init: config{Port: 1, Host: "code"},
+			vars: Map{"PORT": "2"},
+			want: config{Port: 2, Host: "code", Name: "app"},
+		},
+		{
+			name: "keep tag overwritten when set",
+			init: config{Host: "code"},
+			vars: Map{"PORT": "2", "HOST": "env"},
+			want: config{Port: 2, Host: "env", Name: "app"},
+		},
+		{
+			name: "zero values are not kept",
+			vars: Map{"PORT": "2"},
+			err:  true,
+		},
+		{
+			name: "keep existing",
+			opts: []Option{WithKeepExisting()},
+			init: config{Port: 8080, Host: "code", Name: "code"},
+			want: config{Port: 8080, Host: "code", Name: "code"},
+		},
+		{
+			name: "keep existing overwritten when set",
+			opts: []Option{WithKeepExisting()},
+			init: config{Port: 8080, Host: "code"},
+			vars: Map{"PORT": "9090"},
+			want: config{Port: 9090, Host: "code", Name: "app"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.init
+			err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestKeepExistingOnSet(t *testing.T) {
+	type config struct {
+		Host  string `env:"HOST,keep"`
+		Token string `env:"TOKEN,keep,secret"`
+		Port  int    `env:"PORT,keep,optional"`
+	}
+
+	type set struct {
+		env, value, source string
+	}
+
+	var got []set
+	cfg := config{Host: "code", Token: "hunter2"}
+	err := ParseWithOptions(&cfg, WithLookuper(Map{}), WithHermetic(),
+		WithOnSet(func(f FieldInfo, value, source string) {
+			got = append(got, set{f.Env, value, source})
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []set{{"HOST", "code", SourceExisting}, {"TOKEN", Redacted, SourceExisting}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, found %q", want, got)
+	}
+}
+
+type EmbeddedCommon struct {
+	Name string `env:"NAME,optional"`
+}
+
+type embeddedLogging struct {
+	Level string `env:"LEVEL,optional"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	type flattened struct {
+		EmbeddedCommon
+		embeddedLogging
+	}
+	type prefixed struct {
+		EmbeddedCommon `envPrefix:"COMMON_"`
+		Name           string `env:"NAME,optional"`
+	}
+	type skipped struct {
+		EmbeddedCommon `env:"-"`
+		embeddedLogging
+	}
+
+	vars := Map{"NAME": "name", "LEVEL": "debug", "COMMON_NAME": "common"}
+
+	tests := []struct {
+		name string
+		obj  interface{}
+		want interface{}
+	}{
+		{
+			name: "flattened",
+			obj:  &flattened{},
+			want: &flattened{EmbeddedCommon: EmbeddedCommon{Name: "name"}, embeddedLogging: embeddedLogging{Level: "debug"}},
+		},
+		{
+			name: "prefixed",
+			obj:  &prefixed{},
+			want: &prefixed{EmbeddedCommon: EmbeddedCommon{Name: "common"}, Name: "name"},
+		},
+		{
+			name: "skipped",
+			obj:  &skipped{},
+			want: &skipped{embeddedLogging: embeddedLogging{Level: "debug"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ParseWithOptions(tt.obj, WithLookuper(vars), WithHermetic()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.obj, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, tt.obj)
+			}
+		})
+	}
+}
+
+func TestDeprecatedNames(t *testing.T) {
+	type config struct {
+		URL  string `env:"DATABASE_URL|DB_URL,deprecated=DB_DSN,deprecated=DSN"`
+		Port int    `env:"PORT,optional,deprecated=LISTEN_PORT"`
+	}
+
+	type notice struct{ field, env, deprecated string }
+
+	tests := []struct {
+		name    string
+		vars    Map
+		want    config
+		notices []notice
+	}{
+		{
+			name: "current name",
+			vars: Map{"DATABASE_URL": "new", "DB_DSN": "old"},
+			want: config{URL: "new"},
+		},
+		{
+			name: "alias is not deprecated",
+			vars: Map{"DB_URL": "alias", "DB_DSN": "old"},
+			want: config{URL: "alias"},
+		},
+		{
+			name:    "deprecated name",
+			vars:    Map{"DB_DSN": "old", "LISTEN_PORT": "80"},
+			want:    config{URL: "old", Port: 80},
+			notices: []notice{{"URL", "DATABASE_URL", "DB_DSN"}, {"Port", "PORT", "LISTEN_PORT"}},
+		},
+		{
+			name:    "later deprecated name",
+			vars:    Map{"DSN": "older"},
+			want:    config{URL: "older"},
+			notices: []notice{{"URL", "DATABASE_URL", "DSN"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var notices []notice
+			onDeprecated := WithOnDeprecated(func(field FieldInfo, deprecated string) {
+				notices = append(notices, notice{field.Path, field.Env, deprecated})
+			})
+
+			var cfg config
+			if err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic(), onDeprecated); err != nil {
+				t.Fatal(err)
+			}
+			if cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+			if !reflect.DeepEqual(notices, tt.notices) {
+				t.Errorf("expected notices %v, found %v", tt.notices, notices)
+			}
+		})
+	}
+
+	t.Run("without a callback", func(t *testing.T) {
+		var cfg config
+		if err := ParseFromMap(&cfg, map[string]string{"DB_DSN": "old"}); err != nil || cfg.URL != "old" {
+			t.Errorf("expected 'old', found '%s', %v", cfg.URL, err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		var cfg config
+		err := ParseWithOptions(&cfg, WithLookuper(Map{}), WithHermetic())
+		want := "missing required env 'DATABASE_URL' (or 'DB_URL', or deprecated 'DB_DSN', 'DSN') for field 'URL'"
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q, found %v", want, err)
+		}
+	})
+}
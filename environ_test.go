@@ -0,0 +1,49 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEnviron(t *testing.T) {
+	type config struct {
+		Host  string `env:"HOST"`
+		Port  int    `env:"PORT,default=80"`
+		Query string `env:"QUERY,optional"`
+	}
+
+	tests := []struct {
+		name    string
+		environ []string
+		want    config
+		err     bool
+	}{
+		{name: "entries", environ: []string{"HOST=localhost", "PORT=8080"}, want: config{Host: "localhost", Port: 8080}},
+		{name: "later entries override", environ: []string{"HOST=a", "HOST=b"}, want: config{Host: "b", Port: 80}},
+		{name: "values with equals", environ: []string{"HOST=h", "QUERY=a=b"}, want: config{Host: "h", Port: 80, Query: "a=b"}},
+		{name: "malformed entries", environ: []string{"HOST", "=x", "HOST=h"}, want: config{Host: "h", Port: 80}},
+		{name: "missing", environ: nil, err: true},
+	}
+
+	parsers := map[string]func(obj interface{}, environ []string) error{
+		"ParseEnviron":     func(obj interface{}, environ []string) error { return ParseEnviron(environ, obj) },
+		"ParseFromEnviron": ParseFromEnviron,
+	}
+
+	for _, tt := range tests {
+		for name, parse := range parsers {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				t.Setenv("HOST", "from process")
+
+				var cfg config
+				err := parse(&cfg, tt.environ)
+				if (err != nil) != tt.err {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if !tt.err && !reflect.DeepEqual(cfg, tt.want) {
+					t.Errorf("expected %+v, found %+v", tt.want, cfg)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package envtest provides helpers for testing code that reads configuration
+// with env: scoped process variables, a recording Lookuper and golden files
+// for the output of Describe and Marshal.
+//
+//	func TestConfig(t *testing.T) {
+//		var cfg Config
+//		envtest.Parse(t, &cfg, map[string]string{"PORT": "8080"})
+//		envtest.AssertDescribe(t, &cfg, "testdata/config.golden")
+//	}
+//
+// Golden files are rewritten with the actual output when the tests run with
+// -envtest.update.
+package envtest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/reverted/env"
+)
+
+var update = flag.Bool("envtest.update", false, "update envtest golden files")
+
+// Set sets the given process variables for the duration of the test,
+// restoring their previous values when it ends. Like t.Setenv, it cannot be
+// used in parallel tests.
+func Set(t testing.TB, vars map[string]string) {
+	t.Helper()
+
+	for key, value := range vars {
+		t.Setenv(key, value)
+	}
+}
+
+// Unset removes the given process variables for the duration of the test,
+// restoring them when it ends.
+func Unset(t testing.TB, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		// t.Setenv records the previous value and rules out parallel tests
+		t.Setenv(key, "")
+		if err := os.Unsetenv(key); err != nil {
+			t.Fatalf("error unsetting env '%s' : %s", key, err)
+		}
+	}
+}
+
+// Lookuper resolves variables from a fixed map and records the keys looked
+// up, in order.
+type Lookuper struct {
+	vars map[string]string
+
+	mu     sync.Mutex
+	looked []string
+}
+
+// NewLookuper returns a Lookuper over a copy of vars.
+func NewLookuper(vars map[string]string) *Lookuper {
+	l := &Lookuper{vars: make(map[string]string, len(vars))}
+	for key, value := range vars {
+		l.vars[key] = value
+	}
+	return l
+}
+
+func (l *Lookuper) Lookup(key string) (string, bool) {
+	l.mu.Lock()
+	l.looked = append(l.looked, key)
+	l.mu.Unlock()
+
+	value, ok := l.vars[key]
+	return value, ok
+}
+
+// Keys returns the variables in sorted order.
+func (l *Lookuper) Keys() []string {
+	keys := make([]string, 0, len(l.vars))
+	for key := range l.vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Looked returns the keys looked up so far.
+func (l *Lookuper) Looked() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.looked...)
+}
+
+// Parse parses obj from vars alone, without the process environment or
+// dotenv files, and fails the test on error.
+func Parse(t testing.TB, obj interface{}, vars map[string]string, opts ...env.Option) {
+	t.Helper()
+
+	opts = append([]env.Option{env.WithLookuper(NewLookuper(vars)), env.WithHermetic()}, opts...)
+	if err := env.ParseWithOptions(obj, opts...); err != nil {
+		t.Fatalf("error parsing '%T' : %s", obj, err)
+	}
+}
+
+// AssertDescribe compares the schema of obj, as written by env.WriteSchema,
+// with the golden file at path.
+func AssertDescribe(t testing.TB, obj interface{}, path string, opts ...env.Option) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := env.WriteSchema(&buf, obj, opts...); err != nil {
+		t.Fatalf("error describing '%T' : %s", obj, err)
+	}
+	assertGolden(t, buf.Bytes(), path)
+}
+
+// AssertMarshal compares the variables of obj, as written by
+// env.MarshalWriter, with the golden file at path.
+func AssertMarshal(t testing.TB, obj interface{}, path string, opts ...env.Option) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := env.MarshalWriter(obj, &buf, opts...); err != nil {
+		t.Fatalf("error marshaling '%T' : %s", obj, err)
+	}
+	assertGolden(t, buf.Bytes(), path)
+}
+
+func assertGolden(t testing.TB, got []byte, path string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("error updating golden file '%s' : %s", path, err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("error updating golden file '%s' : %s", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading golden file '%s' : %s, run with -envtest.update to create it", path, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match golden file '%s', run with -envtest.update to accept it\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package envtest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/reverted/env"
+)
+
+type config struct {
+	Host string `env:"ENVTEST_HOST"`
+	Port int    `env:"ENVTEST_PORT,default=80"`
+}
+
+// recorder records failures instead of failing the test. Unlike the real
+// Fatalf, its Fatalf returns.
+type recorder struct {
+	testing.TB
+	failed []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed = append(r.failed, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func TestSetAndUnset(t *testing.T) {
+	t.Setenv("ENVTEST_PORT", "9000")
+
+	t.Run("scoped", func(t *testing.T) {
+		Set(t, map[string]string{"ENVTEST_HOST": "localhost"})
+		Unset(t, "ENVTEST_PORT")
+
+		var cfg config
+		if err := env.Parse(&cfg); err != nil {
+			t.Fatal(err)
+		}
+		if want := (config{Host: "localhost", Port: 80}); cfg != want {
+			t.Errorf("expected %+v, found %+v", want, cfg)
+		}
+	})
+
+	if _, ok := os.LookupEnv("ENVTEST_HOST"); ok {
+		t.Error("expected ENVTEST_HOST to be unset after the subtest")
+	}
+	if port := os.Getenv("ENVTEST_PORT"); port != "9000" {
+		t.Errorf("expected ENVTEST_PORT to be restored, found '%s'", port)
+	}
+}
+
+func TestLookuper(t *testing.T) {
+	vars := map[string]string{"ENVTEST_PORT": "8080", "ENVTEST_HOST": "localhost"}
+	l := NewLookuper(vars)
+	vars["ENVTEST_HOST"] = "changed"
+
+	var cfg config
+	if err := env.ParseWithOptions(&cfg, env.WithLookuper(l), env.WithHermetic()); err != nil {
+		t.Fatal(err)
+	}
+	if want := (config{Host: "localhost", Port: 8080}); cfg != want {
+		t.Errorf("expected %+v, found %+v", want, cfg)
+	}
+
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []string{"ENVTEST_HOST", "ENVTEST_PORT"}) {
+		t.Errorf("unexpected keys %q", keys)
+	}
+	if looked := l.Looked(); !reflect.DeepEqual(looked, []string{"ENVTEST_HOST", "ENVTEST_PORT"}) {
+		t.Errorf("unexpected keys looked up %q", looked)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Setenv("ENVTEST_HOST", "from the environment")
+
+	var cfg config
+	Parse(t, &cfg, map[string]string{"ENVTEST_HOST": "localhost"})
+	if want := (config{Host: "localhost", Port: 80}); cfg != want {
+		t.Errorf("expected %+v, found %+v", want, cfg)
+	}
+
+	r := &recorder{TB: t}
+	Parse(r, &config{}, map[string]string{})
+	if len(r.failed) != 1 {
+		t.Errorf("expected a missing variable to fail, found %q", r.failed)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	cfg := config{Host: "localhost", Port: 8080}
+	path := filepath.Join(t.TempDir(), "testdata", "config.golden")
+
+	*update = true
+	AssertMarshal(t, &cfg, path)
+	*update = false
+
+	var buf bytes.Buffer
+	if err := env.MarshalWriter(&cfg, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := os.ReadFile(path); err != nil || !bytes.Equal(written, buf.Bytes()) {
+		t.Fatalf("expected the golden file to hold %q, found %q, %v", buf.Bytes(), written, err)
+	}
+
+	AssertMarshal(t, &cfg, path)
+
+	missing := filepath.Join(t.TempDir(), "missing.golden")
+	for name, assert := range map[string]func(testing.TB){
+		"changed":  func(tb testing.TB) { AssertMarshal(tb, &config{Host: "other"}, path) },
+		"describe": func(tb testing.TB) { AssertDescribe(tb, &cfg, path) },
+		"missing":  func(tb testing.TB) { AssertMarshal(tb, &cfg, missing) },
+	} {
+		r := &recorder{TB: t}
+		if assert(r); len(r.failed) == 0 {
+			t.Errorf("%s: expected the assertion to fail", name)
+		}
+	}
+}
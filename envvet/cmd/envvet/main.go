@@ -0,0 +1,11 @@
+// Command envvet checks env struct tags, see package envvet.
+package main
+
+import (
+	"github.com/reverted/env/envvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(envvet.Analyzer)
+}
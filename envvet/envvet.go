@@ -0,0 +1,292 @@
+// Package envvet defines an analyzer that checks env struct tags, reporting
+// the mistakes Parse would only find at runtime, such as conflicting options
+// and variable names used more than once, along with unknown options.
+//
+// Run it with go vet through the envvet command:
+//
+//	go install ./cmd/envvet
+//	go vet -vettool=$(which envvet) ./...
+//
+// envvet is a module of its own that builds against the env in the parent
+// directory until a release of env holds the API it uses, so install it from
+// a checkout of the repository as above.
+package envvet
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/reverted/env"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "envvet",
+	Doc:      "check env struct tags",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// valueTypes lists additional types, such as those with parsers added with
+// env.RegisterParser, as comma-separated import path qualified names.
+var valueTypes string
+
+func init() {
+	Analyzer.Flags.StringVar(&valueTypes, "types", "", "comma-separated list of types with registered parsers, e.g. example.com/pkg.Type")
+}
+
+type checker struct {
+	pass     *analysis.Pass
+	types    map[string]bool
+	reported map[string]bool
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	c := &checker{pass: pass, types: map[string]bool{}, reported: map[string]bool{}}
+	for _, name := range strings.Split(valueTypes, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			c.types[name] = true
+		}
+	}
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	insp.Preorder([]ast.Node{(*ast.TypeSpec)(nil)}, func(n ast.Node) {
+		spec := n.(*ast.TypeSpec)
+		if _, ok := spec.Type.(*ast.StructType); !ok {
+			return
+		}
+
+		obj := pass.TypesInfo.Defs[spec.Name]
+		if obj == nil {
+			return
+		}
+
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			return
+		}
+
+		names := map[string]string{}
+		c.checkStruct(st, "", "", nil, names, map[*types.Struct]bool{})
+	})
+
+	return nil, nil
+}
+
+// checkStruct walks st the way Parse does, checking every tagged field and
+// recording the variable names seen so far in names. Duplicate names inside
+// nested structs are reported at the outermost field at, since the nested
+// struct is usually fine on its own.
+func (c *checker) checkStruct(st *types.Struct, prefix, path string, at *types.Var, names map[string]string, seen map[*types.Struct]bool) {
+	if seen[st] {
+		return
+	}
+	seen[st] = true
+	defer delete(seen, st)
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+
+		raw, ok := tag.Lookup(env.TagName)
+		if raw == "-" {
+			continue
+		}
+
+		// unexported embedded structs are flattened, their tags are checked
+		// like those of exported ones
+		if !field.Exported() {
+			if _, isStruct := field.Type().Underlying().(*types.Struct); !field.Anonymous() || ok || !isStruct {
+				continue
+			}
+		}
+
+		fieldPath := joinPath(path, field.Name())
+
+		// structs gated by onlyIf are descended into like untagged ones
+		if parsed, _, err := env.ParseTag(tag); ok && err == nil && parsed.Env == "" && len(parsed.OnlyIf) > 0 {
+			if _, isStruct := deref(field.Type()).Underlying().(*types.Struct); isStruct && !c.isValueType(field.Type()) {
+				ok = false
+			}
+		}
+
+		if !ok {
+			_, hasPrefix := tag.Lookup(env.PrefixTagName)
+
+			nested, ok := structOf(field.Type(), hasPrefix)
+			if !ok || c.isValueType(field.Type()) {
+				continue
+			}
+
+			// slices and maps of structs are read under numbered or keyed
+			// prefixes, so their names cannot collide with the parent's
+			fieldNames := names
+			if _, isStruct := deref(field.Type()).Underlying().(*types.Struct); !isStruct {
+				fieldNames = map[string]string{}
+			}
+
+			nestedPath := fieldPath
+			if field.Anonymous() {
+				nestedPath = path
+			}
+
+			nestedAt := at
+			if nestedAt == nil {
+				nestedAt = field
+			}
+			c.checkStruct(nested, prefix+tag.Get(env.PrefixTagName), nestedPath, nestedAt, fieldNames, seen)
+			continue
+		}
+
+		parsed, _, err := env.ParseTag(tag)
+		if err != nil {
+			c.report(field, "%s", err)
+			continue
+		}
+
+		for _, option := range parsed.Unknown {
+			c.report(field, "unknown option '%s' in tag '%s'", option, env.TagName)
+		}
+
+		if len(parsed.OnlyIf) > 0 {
+			c.report(field, "onlyIf of field '%s' is only supported on nested structs", fieldPath)
+		}
+
+		if !c.supports(field.Type(), parsed) {
+			c.report(field, "unsupported type '%s' of field '%s'", field.Type(), fieldPath)
+		}
+
+		if parsed.Env == "" {
+			continue
+		}
+
+		for _, name := range parsed.Names() {
+			name = prefix + name
+			if other, ok := names[name]; ok {
+				dup := field
+				if at != nil {
+					dup = at
+				}
+				c.report(dup, "env '%s' of field '%s' is also used by field '%s'", name, fieldPath, other)
+				continue
+			}
+			names[name] = fieldPath
+		}
+	}
+}
+
+func (c *checker) report(field *types.Var, format string, args ...interface{}) {
+	if field.Pkg() != c.pass.Pkg {
+		return
+	}
+
+	key := strconv.Itoa(int(field.Pos())) + format
+	if c.reported[key] {
+		return
+	}
+	c.reported[key] = true
+
+	c.pass.Reportf(field.Pos(), format, args...)
+}
+
+// supports mirrors the types Parse can decode into.
+func (c *checker) supports(t types.Type, tag env.Tag) bool {
+	if tag.JSON || c.isValueType(t) {
+		return true
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		info := u.Info()
+		return info&(types.IsString|types.IsBoolean|types.IsInteger|types.IsFloat) != 0 && info&types.IsUntyped == 0
+
+	case *types.Pointer:
+		return c.supports(u.Elem(), tag)
+
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return true
+		}
+		return c.supports(u.Elem(), tag)
+
+	case *types.Array:
+		return c.supports(u.Elem(), tag)
+
+	case *types.Map:
+		return c.supports(u.Key(), tag) && c.supports(u.Elem(), tag)
+	}
+
+	return false
+}
+
+// isValueType reports whether t is decoded as a whole, through env.Setter,
+// encoding.TextUnmarshaler or a parser.
+func (c *checker) isValueType(t types.Type) bool {
+	t = deref(t)
+
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		name := named.Obj().Pkg().Path() + "." + named.Obj().Name()
+		switch name {
+		case "net/url.URL", "net.IPNet":
+			return true
+		}
+		if c.types[name] {
+			return true
+		}
+	}
+
+	for _, method := range []string{"SetEnv", "UnmarshalText"} {
+		obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, nil, method)
+		if _, ok := obj.(*types.Func); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// structOf returns the struct a nested field is descended into: structs,
+// pointers to structs and, with an envPrefix tag, the element of slices and
+// maps of structs.
+func structOf(t types.Type, hasPrefix bool) (*types.Struct, bool) {
+	t = deref(t)
+
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		return u, true
+	}
+
+	if !hasPrefix {
+		return nil, false
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		st, ok := deref(u.Elem()).Underlying().(*types.Struct)
+		return st, ok
+
+	case *types.Map:
+		st, ok := deref(u.Elem()).Underlying().(*types.Struct)
+		return st, ok
+	}
+
+	return nil, false
+}
+
+func deref(t types.Type) types.Type {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,13 @@
+module github.com/reverted/env/envvet
+
+go 1.22.0
+
+require github.com/reverted/env v0.0.0
+
+require (
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+	golang.org/x/tools v0.30.0
+)
+
+replace github.com/reverted/env => ../
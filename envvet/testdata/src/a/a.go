@@ -0,0 +1,62 @@
+package a
+
+import (
+	"net/url"
+	"time"
+)
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error { return nil }
+
+type Valid struct {
+	Host    string              `env:"HOST|HOSTNAME"`
+	Port    int                 `env:"PORT,default=80"`
+	Timeout time.Duration       `env:"TIMEOUT,optional"`
+	URL     url.URL             `env:"URL,optional"`
+	Level   level               `env:"LEVEL,optional"`
+	Key     []byte              `env:"KEY,encoding=hex,optional"`
+	Labels  map[string]string   `env:"LABELS,optional"`
+	Raw     chan int            `env:"RAW,json,optional"`
+	Skipped chan int            `env:"-"`
+	DB      Database            `envPrefix:"DB_"`
+	Replica *Database           `envPrefix:"REPLICA_"`
+	Servers []Database          `envPrefix:"SERVERS_"`
+	Zones   map[string]Database `envPrefix:"ZONE_"`
+	TLS     TLS                 `env:",onlyIf=TLS_ENABLED" envPrefix:"TLS_"`
+}
+
+type Database struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT,optional"`
+}
+
+type TLS struct {
+	Cert string `env:"CERT"`
+}
+
+type Invalid struct {
+	Conflict string   `env:"CONFLICT,required,default=x"` // want `conflicting options 'required' and 'default'`
+	Unknown  string   `env:"UNKNOWN,optinal"`             // want `unknown option 'optinal' in tag 'env'`
+	Channel  chan int `env:"CHANNEL"`                     // want `unsupported type 'chan int' of field 'Channel'`
+	Func     func()   `env:"FUNC"`                        // want `unsupported type 'func\(\)' of field 'Func'`
+	Cert     string   `env:"CERT,onlyIf=TLS"`             // want `onlyIf of field 'Cert' is only supported on nested structs`
+	Host     string   `env:"HOST"`
+	Hostname string   `env:"HOSTNAME|HOST"` // want `env 'HOST' of field 'Hostname' is also used by field 'Host'`
+}
+
+type Duplicates struct {
+	Host string   `env:"DB_HOST"`
+	DB   Database `envPrefix:"DB_"` // want `env 'DB_HOST' of field 'DB.Host' is also used by field 'Host'`
+	// elements are read under indexed names, which cannot collide
+	Servers []Database `envPrefix:"DB_"`
+}
+
+type embedded struct {
+	Name string `env:"NAME"`
+}
+
+type Embedding struct {
+	embedded
+	Name string `env:"NAME"` // want `env 'NAME' of field 'Name' is also used by field 'Name'`
+}
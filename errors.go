@@ -0,0 +1,194 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrMissing     = errors.New("missing required env")
+	ErrParse       = errors.New("error parsing env")
+	ErrUnsupported = errors.New("unsupported field type")
+	ErrEmpty       = errors.New("empty env")
+	ErrLookup      = errors.New("error looking up env")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnknown     = errors.New("unknown env")
+	ErrConflict    = errors.New("conflicting env")
+
+	ErrInvalidTarget = errors.New("target must be a non-nil pointer to a struct")
+)
+
+type MissingError struct {
+	Env     string
+	Field   string
+	Aliases []string
+
+	// Deprecated holds the field's deprecated names, which are still read
+	// and listed in the message as such.
+	Deprecated []string
+
+	// Description is the field's desc tag, included in the message so it
+	// says what the variable is for.
+	Description string
+}
+
+func (e MissingError) Error() string {
+	var others []string
+	if len(e.Aliases) > 0 {
+		others = append(others, "'"+strings.Join(e.Aliases, "', '")+"'")
+	}
+	if len(e.Deprecated) > 0 {
+		others = append(others, "deprecated '"+strings.Join(e.Deprecated, "', '")+"'")
+	}
+	if len(others) > 0 {
+		return fmt.Sprintf("missing required env '%s' (or %s) for field '%s'%s", e.Env, strings.Join(others, ", or "), e.Field, describe(e.Description))
+	}
+	return fmt.Sprintf("missing required env '%s' for field '%s'%s", e.Env, e.Field, describe(e.Description))
+}
+
+func (e MissingError) Is(target error) bool {
+	return target == ErrMissing
+}
+
+type EmptyError struct {
+	Env         string
+	Field       string
+	Description string
+}
+
+func (e EmptyError) Error() string {
+	return fmt.Sprintf("empty env '%s' for field '%s'%s", e.Env, e.Field, describe(e.Description))
+}
+
+// describe formats the first line of a description for an error message.
+func describe(desc string) string {
+	desc, _, _ = strings.Cut(desc, "\n")
+	if desc = strings.TrimSpace(desc); desc == "" {
+		return ""
+	}
+	return " (" + desc + ")"
+}
+
+func (e EmptyError) Is(target error) bool {
+	return target == ErrEmpty
+}
+
+type ParseError struct {
+	Env   string
+	Field string
+	Err   error
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("error parsing env '%s' for field '%s' : %v", e.Env, e.Field, e.Err)
+}
+
+func (e ParseError) Is(target error) bool {
+	return target == ErrParse
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+type LookupError struct {
+	Env   string
+	Field string
+	Err   error
+}
+
+func (e LookupError) Error() string {
+	return fmt.Sprintf("error looking up env '%s' for field '%s' : %v", e.Env, e.Field, e.Err)
+}
+
+func (e LookupError) Is(target error) bool {
+	return target == ErrLookup
+}
+
+func (e LookupError) Unwrap() error {
+	return e.Err
+}
+
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("validation failed : %v", e.Err)
+	}
+	return fmt.Sprintf("validation failed for field '%s' : %v", e.Field, e.Err)
+}
+
+func (e ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+type RuleError struct {
+	Env   string
+	Field string
+	Rule  string
+	Value string
+	Err   error
+}
+
+func (e RuleError) Error() string {
+	return fmt.Sprintf("env '%s' for field '%s' failed rule '%s' with value '%s' : %v", e.Env, e.Field, e.Rule, e.Value, e.Err)
+}
+
+func (e RuleError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func (e RuleError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError is a variable or field that does not hold a valid value, as
+// reported by Check. Err is the error Parse would return for it.
+type FieldError struct {
+	Env   string
+	Field string
+	Err   error
+}
+
+func (e FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+type UnsupportedError struct {
+	Env   string
+	Field string
+	Type  reflect.Type
+}
+
+func (e UnsupportedError) Error() string {
+	return fmt.Sprintf("unsupported type '%s' of env '%s' for field '%s'", e.Type, e.Env, e.Field)
+}
+
+func (e UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+type UnknownError struct {
+	Env string
+}
+
+func (e UnknownError) Error() string {
+	return fmt.Sprintf("unknown env '%s'", e.Env)
+}
+
+func (e UnknownError) Is(target error) bool {
+	return target == ErrUnknown
+}
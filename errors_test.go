@@ -0,0 +1,206 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseCollectsErrors(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST"`
+		Port    int    `env:"PORT"`
+		User    string `env:"USER"`
+		Debug   bool   `env:"DEBUG"`
+		Workers uint8  `env:"WORKERS,optional"`
+	}
+
+	var cfg config
+	err := ParseWithOptions(&cfg, WithLookuper(Map{"PORT": "http", "USER": "admin", "WORKERS": "300"}), WithHermetic())
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+
+	var envs []string
+	for _, e := range unwrapErrors(err) {
+		switch e := e.(type) {
+		case MissingError:
+			envs = append(envs, "missing "+e.Env)
+		case ParseError:
+			envs = append(envs, "parse "+e.Env)
+		default:
+			t.Errorf("unexpected error %T %v", e, e)
+		}
+	}
+
+	want := []string{"missing HOST", "parse PORT", "missing DEBUG", "parse WORKERS"}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("expected %q, found %q", want, envs)
+	}
+	if cfg.User != "admin" {
+		t.Errorf("expected the valid fields to be set, found %+v", cfg)
+	}
+	if !errors.Is(err, ErrMissing) || !errors.Is(err, ErrParse) {
+		t.Errorf("expected the joined error to match ErrMissing and ErrParse")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	cause := errors.New("cause")
+
+	tests := []struct {
+		err      error
+		sentinel error
+		msg      string
+	}{
+		{
+			err:      MissingError{Env: "HOST", Field: "Server.Host"},
+			sentinel: ErrMissing,
+			msg:      "missing required env 'HOST' for field 'Server.Host'",
+		},
+		{
+			err:      MissingError{Env: "HOST", Field: "Host", Aliases: []string{"HOSTNAME", "SERVER"}, Description: "server host\nmore"},
+			sentinel: ErrMissing,
+			msg:      "missing required env 'HOST' (or 'HOSTNAME', 'SERVER') for field 'Host' (server host)",
+		},
+		{
+			err:      MissingError{Env: "HOST", Field: "Host", Deprecated: []string{"OLD_HOST"}},
+			sentinel: ErrMissing,
+			msg:      "missing required env 'HOST' (or deprecated 'OLD_HOST') for field 'Host'",
+		},
+		{
+			err:      MissingError{Env: "HOST", Field: "Host", Aliases: []string{"HOSTNAME"}, Deprecated: []string{"OLD_HOST", "SERVER"}},
+			sentinel: ErrMissing,
+			msg:      "missing required env 'HOST' (or 'HOSTNAME', or deprecated 'OLD_HOST', 'SERVER') for field 'Host'",
+		},
+		{
+			err:      EmptyError{Env: "NAME", Field: "Name"},
+			sentinel: ErrEmpty,
+			msg:      "empty env 'NAME' for field 'Name'",
+		},
+		{
+			err:      ParseError{Env: "PORT", Field: "Port", Err: cause},
+			sentinel: ErrParse,
+			msg:      "error parsing env 'PORT' for field 'Port' : cause",
+		},
+		{
+			err:      LookupError{Env: "TOKEN", Field: "Token", Err: cause},
+			sentinel: ErrLookup,
+			msg:      "error looking up env 'TOKEN' for field 'Token' : cause",
+		},
+		{
+			err:      ValidationError{Err: cause},
+			sentinel: ErrValidation,
+			msg:      "validation failed : cause",
+		},
+		{
+			err:      ValidationError{Field: "DB", Err: cause},
+			sentinel: ErrValidation,
+			msg:      "validation failed for field 'DB' : cause",
+		},
+		{
+			err:      RuleError{Env: "PORT", Field: "Port", Rule: "min=1", Value: "0", Err: cause},
+			sentinel: ErrValidation,
+			msg:      "env 'PORT' for field 'Port' failed rule 'min=1' with value '0' : cause",
+		},
+		{
+			err:      UnsupportedError{Env: "CH", Field: "Ch", Type: reflect.TypeOf(make(chan int))},
+			sentinel: ErrUnsupported,
+			msg:      "unsupported type 'chan int' of env 'CH' for field 'Ch'",
+		},
+		{
+			err:      UnknownError{Env: "APP_HOTS"},
+			sentinel: ErrUnknown,
+			msg:      "unknown env 'APP_HOTS'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.msg {
+				t.Errorf("expected %q, found %q", tt.msg, got)
+			}
+			if !errors.Is(tt.err, tt.sentinel) {
+				t.Errorf("expected %T to match %v", tt.err, tt.sentinel)
+			}
+			if _, ok := tt.err.(interface{ Unwrap() error }); ok && !errors.Is(tt.err, cause) {
+				t.Errorf("expected %T to wrap its cause", tt.err)
+			}
+		})
+	}
+}
+
+func TestErrorFieldPaths(t *testing.T) {
+	type http struct {
+		Port int `env:"PORT"`
+	}
+	type server struct {
+		HTTP  http  `envPrefix:"HTTP_"`
+		Admin *http `envPrefix:"ADMIN_"`
+	}
+	type config struct {
+		Port   int    `env:"PORT"`
+		Server server `envPrefix:"SERVER_"`
+	}
+
+	var cfg config
+	err := ParseFromMap(&cfg, map[string]string{"PORT": "x", "SERVER_ADMIN_PORT": "y"})
+
+	type fieldEnv struct{ field, env string }
+
+	var got []fieldEnv
+	for _, e := range unwrapErrors(err) {
+		switch e := e.(type) {
+		case MissingError:
+			got = append(got, fieldEnv{e.Field, e.Env})
+		case ParseError:
+			got = append(got, fieldEnv{e.Field, e.Env})
+		default:
+			t.Errorf("unexpected error %T %v", e, e)
+		}
+	}
+
+	want := []fieldEnv{
+		{"Port", "PORT"},
+		{"Server.HTTP.Port", "SERVER_HTTP_PORT"},
+		{"Server.Admin.Port", "SERVER_ADMIN_PORT"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, found %v", want, got)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	type config struct {
+		URL  string `env:"DB_URL" desc:"PostgreSQL connection string"`
+		Name string `env:"NAME,notEmpty" desc:"service name"`
+		Port int    `env:"PORT,optional"`
+	}
+
+	var cfg config
+	err := ParseFromMap(&cfg, map[string]string{"NAME": "", "PORT": "x"})
+
+	want := []string{
+		"missing required env 'DB_URL' for field 'URL' (PostgreSQL connection string)",
+		"empty env 'NAME' for field 'Name' (service name)",
+	}
+	var got []string
+	for _, e := range unwrapErrors(err) {
+		if _, ok := e.(ParseError); !ok {
+			got = append(got, e.Error())
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, found %q", want, got)
+	}
+
+	specs, err := Describe(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, desc := range []string{"PostgreSQL connection string", "service name", ""} {
+		if specs[i].Description != desc {
+			t.Errorf("expected description '%s' for %s, found '%s'", desc, specs[i].Name, specs[i].Description)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package env
+
+import (
+	"reflect"
+	"sync"
+)
+
+// field is a struct field with its parsed tag, the part of the field
+// metadata that does not depend on options.
+type field struct {
+	reflect.StructField
+
+	// raw and hasTag hold the env tag, hasPrefix whether an envPrefix tag is
+	// present, saving the tag lookups when classifying.
+	raw       string
+	hasTag    bool
+	hasPrefix bool
+
+	tag    Tag
+	tagErr error
+}
+
+// fieldCache holds the fields of every struct type parsed so far, so repeated
+// parses skip reflecting on and parsing tags. Fields read with another tag
+// style are cached in styledCache.
+var (
+	fieldCache  sync.Map // map[reflect.Type][]field
+	styledCache sync.Map // map[styledKey][]field
+)
+
+type styledKey struct {
+	t     reflect.Type
+	style tagStyle
+}
+
+func fieldsOf(t reflect.Type, style tagStyle) []field {
+	if style == (tagStyle{}) {
+		if cached, ok := fieldCache.Load(t); ok {
+			return cached.([]field)
+		}
+	} else if cached, ok := styledCache.Load(styledKey{t: t, style: style}); ok {
+		return cached.([]field)
+	}
+
+	fields := make([]field, t.NumField())
+	for i := range fields {
+		tField := t.Field(i)
+		raw, hasTag := tField.Tag.Lookup(style.tagName())
+		_, hasPrefix := tField.Tag.Lookup(PrefixTagName)
+
+		tag, _, err := parseTagStyle(tField.Tag, style)
+		fields[i] = field{StructField: tField, raw: raw, hasTag: hasTag, hasPrefix: hasPrefix, tag: tag, tagErr: err}
+	}
+
+	if style == (tagStyle{}) {
+		cached, _ := fieldCache.LoadOrStore(t, fields)
+		return cached.([]field)
+	}
+	cached, _ := styledCache.LoadOrStore(styledKey{t: t, style: style}, fields)
+	return cached.([]field)
+}
@@ -0,0 +1,78 @@
+package env
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestFieldsOf(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST|HOSTNAME" envconfig:"SERVER_HOST"`
+		Port int    `env:"PORT,bad" envPrefix:"X_"`
+		Skip string
+	}
+	typ := reflect.TypeOf(config{})
+
+	fields := fieldsOf(typ, tagStyle{})
+	if again := fieldsOf(typ, tagStyle{}); &again[0] != &fields[0] {
+		t.Error("expected the fields to be cached")
+	}
+
+	styled := fieldsOf(typ, tagStyle{name: "envconfig"})
+	if &styled[0] == &fields[0] || styled[0].tag.Env != "SERVER_HOST" {
+		t.Errorf("expected fields read with another tag style to be cached separately, found %+v", styled[0].tag)
+	}
+
+	tests := []struct {
+		field     field
+		env       string
+		hasTag    bool
+		hasPrefix bool
+		unknown   []string
+	}{
+		{field: fields[0], env: "HOST", hasTag: true},
+		{field: fields[1], env: "PORT", hasTag: true, hasPrefix: true, unknown: []string{"bad"}},
+		{field: fields[2]},
+	}
+
+	for _, tt := range tests {
+		f := tt.field
+		if f.tag.Env != tt.env || f.hasTag != tt.hasTag || f.hasPrefix != tt.hasPrefix || !reflect.DeepEqual(f.tag.Unknown, tt.unknown) {
+			t.Errorf("unexpected field %s : %+v", f.Name, f)
+		}
+	}
+}
+
+func TestFieldCacheSharedAcrossPrefixes(t *testing.T) {
+	type server struct {
+		Host string `env:"HOST|HOSTNAME"`
+	}
+	type config struct {
+		A server `envPrefix:"A_"`
+		B server `envPrefix:"B_"`
+	}
+
+	// the aliases of the cached tag must not be prefixed in place
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var cfg config
+			if err := ParseFromMap(&cfg, map[string]string{"A_HOSTNAME": "a", "B_HOSTNAME": "b"}); err != nil {
+				t.Error(err)
+				return
+			}
+			if cfg.A.Host != "a" || cfg.B.Host != "b" {
+				t.Errorf("unexpected config %+v", cfg)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if aliases := fieldsOf(reflect.TypeOf(server{}), tagStyle{})[0].tag.Aliases; !reflect.DeepEqual(aliases, []string{"HOSTNAME"}) {
+		t.Errorf("expected the cached aliases to be unchanged, found %v", aliases)
+	}
+}
@@ -0,0 +1,38 @@
+package env
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+const FileSuffix = "_FILE"
+
+func (o Options) readFile(name string) (string, error) {
+	var content []byte
+	var err error
+
+	switch {
+	case o.FS != nil:
+		content, err = fs.ReadFile(o.FS, strings.TrimPrefix(path.Clean(name), "/"))
+
+	case o.Hermetic:
+		return "", fmt.Errorf("reading files is not permitted in hermetic mode without a FS")
+
+	default:
+		content, err = os.ReadFile(name)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error reading file '%s' : %w", name, err)
+	}
+
+	return trimNewline(string(content)), nil
+}
+
+func trimNewline(value string) string {
+	value = strings.TrimSuffix(value, "\n")
+	return strings.TrimSuffix(value, "\r")
+}
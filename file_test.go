@@ -0,0 +1,73 @@
+package env
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileValues(t *testing.T) {
+	fsys := fstest.MapFS{
+		"run/secrets/db_password": {Data: []byte("hunter2\n")},
+		"run/secrets/crlf":        {Data: []byte("value\r\n")},
+		"run/secrets/multi":       {Data: []byte("a\nb\n\n")},
+	}
+
+	tests := []struct {
+		name     string
+		tag      reflect.StructTag
+		fallback bool
+		vars     Map
+		want     string
+		err      error
+	}{
+		{name: "file option", tag: `env:"DB_PASSWORD,file"`, vars: Map{"DB_PASSWORD": "/run/secrets/db_password"}, want: "hunter2"},
+		{name: "crlf trimmed", tag: `env:"V,file"`, vars: Map{"V": "run/secrets/crlf"}, want: "value"},
+		{name: "only one newline trimmed", tag: `env:"V,file"`, vars: Map{"V": "run/secrets/multi"}, want: "a\nb\n"},
+		{name: "fallback", tag: `env:"DB_PASSWORD"`, fallback: true, vars: Map{"DB_PASSWORD_FILE": "/run/secrets/db_password"}, want: "hunter2"},
+		{name: "variable preferred to fallback", tag: `env:"DB_PASSWORD"`, fallback: true, vars: Map{"DB_PASSWORD": "direct", "DB_PASSWORD_FILE": "/run/secrets/db_password"}, want: "direct"},
+		{name: "fallback disabled", tag: `env:"DB_PASSWORD,optional"`, vars: Map{"DB_PASSWORD_FILE": "/run/secrets/db_password"}},
+		{name: "from file", tag: `env:"DB_PASSWORD,from=file"`, vars: Map{"DB_PASSWORD": "direct", "DB_PASSWORD_FILE": "/run/secrets/db_password"}, want: "hunter2"},
+		{name: "missing file", tag: `env:"V,file"`, vars: Map{"V": "/run/secrets/missing"}, err: fs.ErrNotExist},
+		{name: "missing fallback file", tag: `env:"V"`, fallback: true, vars: Map{"V_FILE": "/run/secrets/missing"}, err: fs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(""), Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			opts := []Option{WithLookuper(tt.vars), WithHermetic(), WithFS(fsys)}
+			if tt.fallback {
+				opts = append(opts, WithFileFallback())
+			}
+
+			err := ParseWithOptions(v.Interface(), opts...)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, found %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Elem().Field(0).String(); got != tt.want {
+				t.Errorf("expected %q, found %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadFileHermetic(t *testing.T) {
+	type config struct {
+		Key string `env:"KEY,file"`
+	}
+
+	var cfg config
+	if err := ParseWithOptions(&cfg, WithLookuper(Map{"KEY": "/key"}), WithHermetic()); err == nil {
+		t.Error("expected reading a file without a FS to fail in hermetic mode")
+	}
+}
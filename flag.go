@@ -0,0 +1,105 @@
+package env
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FlagName derives the command-line flag name for a variable, e.g. DB_HOST
+// becomes db-host.
+func FlagName(env string) string {
+	return strings.ToLower(strings.ReplaceAll(env, "_", "-"))
+}
+
+// RegisterFlags defines a flag for every variable obj consumes, named with
+// FlagName after stripping the prefix, except those of indexed and keyed
+// fields. Pass the flag set to WithFlags when
+// parsing so flags that were set override the environment. It fails without
+// defining any flag when two variables map to the same flag name or a name is
+// already defined in fs.
+func RegisterFlags(fs *flag.FlagSet, obj interface{}, opts ...Option) error {
+	o := newOptions(opts)
+
+	specs, err := Describe(obj, opts...)
+	if err != nil {
+		return err
+	}
+
+	var flags []VarSpec
+	var names []string
+	fields := map[string]string{}
+	for _, spec := range specs {
+		if spec.Pattern {
+			continue
+		}
+
+		name := FlagName(strings.TrimPrefix(spec.Name, o.Prefix))
+		if other, ok := fields[name]; ok {
+			return fmt.Errorf("flag '%s' of field '%s' is also used by field '%s'", name, spec.Field, other)
+		}
+		if fs.Lookup(name) != nil {
+			return fmt.Errorf("flag '%s' of field '%s' is already defined", name, spec.Field)
+		}
+		fields[name] = spec.Field
+		flags, names = append(flags, spec), append(names, name)
+	}
+
+	for i, spec := range flags {
+		fs.Var(&flagValue{value: spec.Default, isBool: isBoolType(spec.Type)}, names[i], spec.Description)
+	}
+
+	return nil
+}
+
+func isBoolType(name string) bool {
+	return name == "bool" || name == "*bool"
+}
+
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *flagValue) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+type flagSet struct {
+	fs *flag.FlagSet
+}
+
+// FlagSet looks up variables in the flags of fs that were set on the command
+// line.
+func FlagSet(fs *flag.FlagSet) Lookuper {
+	return flagSet{fs: fs}
+}
+
+func (f flagSet) Lookup(key string) (string, bool) {
+	name := FlagName(key)
+
+	var value string
+	var ok bool
+	f.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == name {
+			value, ok = fl.Value.String(), true
+		}
+	})
+	return value, ok
+}
+
+func (f flagSet) SourceName() string {
+	return SourceFlag
+}
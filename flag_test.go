@@ -0,0 +1,143 @@
+package env
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	type config struct {
+		Host    string           `env:"HOST"`
+		Debug   bool             `env:"DEBUG,optional"`
+		Port    int              `env:"PORT,default=80"`
+		Servers []describeServer `envPrefix:"SERVERS_"`
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := RegisterFlags(fs, &config{}, WithPrefix("APP_")); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	if want := []string{"debug", "host", "port"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected flags %q, found %q", want, names)
+	}
+
+	if err := fs.Parse([]string{"-host", "example.com", "-debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config
+	if err := ParseWithOptions(&cfg, WithPrefix("APP_"), WithFlags(FlagSet(fs)), WithLookuper(Map{}), WithHermetic()); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "example.com" || !cfg.Debug || cfg.Port != 80 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestFlagPrecedence(t *testing.T) {
+	type config struct {
+		Host string `env:"DB_HOST,default=localhost" desc:"database host"`
+		Port int    `env:"DB_PORT,default=5432"`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		vars Map
+		want config
+	}{
+		{name: "defaults", want: config{Host: "localhost", Port: 5432}},
+		{name: "environment overrides defaults", vars: Map{"DB_HOST": "env"}, want: config{Host: "env", Port: 5432}},
+		{name: "flags override the environment", args: []string{"-db-host", "flag"}, vars: Map{"DB_HOST": "env", "DB_PORT": "1"}, want: config{Host: "flag", Port: 1}},
+		{name: "flags override defaults", args: []string{"-db-port=6543"}, want: config{Host: "localhost", Port: 6543}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			if err := RegisterFlags(fs, &config{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if f := fs.Lookup("db-host"); f.Usage != "database host" || f.DefValue != "localhost" {
+				t.Errorf("expected usage and default from the tags, found '%s' and '%s'", f.Usage, f.DefValue)
+			}
+
+			var cfg config
+			if err := ParseWithOptions(&cfg, WithFlags(FlagSet(fs)), WithLookuper(tt.vars), WithHermetic()); err != nil {
+				t.Fatal(err)
+			}
+			if cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestFlagName(t *testing.T) {
+	tests := []struct{ env, want string }{
+		{"HOST", "host"},
+		{"DB_HOST", "db-host"},
+		{"TLS_CERT_FILE", "tls-cert-file"},
+	}
+
+	for _, tt := range tests {
+		if got := FlagName(tt.env); got != tt.want {
+			t.Errorf("expected '%s' for '%s', found '%s'", tt.want, tt.env, got)
+		}
+	}
+}
+
+func TestRegisterFlagsConflicts(t *testing.T) {
+	type config struct {
+		Host   string `env:"DB_HOST"`
+		Dashed string `env:"DB-HOST"`
+	}
+	type single struct {
+		Verbose bool `env:"VERBOSE"`
+	}
+
+	tests := []struct {
+		name    string
+		obj     interface{}
+		defined []string
+		err     string
+	}{
+		{name: "same flag name", obj: &config{}, err: "flag 'db-host' of field 'Dashed' is also used by field 'Host'"},
+		{name: "already defined", obj: &single{}, defined: []string{"verbose"}, err: "flag 'verbose' of field 'Verbose' is already defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			for _, name := range tt.defined {
+				fs.Bool(name, false, "")
+			}
+
+			err := RegisterFlags(fs, tt.obj)
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("expected error '%s', found %v", tt.err, err)
+			}
+
+			var names []string
+			fs.VisitAll(func(f *flag.Flag) {
+				names = append(names, f.Name)
+			})
+			if !reflect.DeepEqual(names, tt.defined) {
+				t.Errorf("expected flags %q, found %q", tt.defined, names)
+			}
+		})
+	}
+}
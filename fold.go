@@ -0,0 +1,78 @@
+package env
+
+import (
+	"sort"
+	"strings"
+)
+
+// folded is the case-insensitive view of the sources used by
+// Options.CaseInsensitive, built once per parse.
+type folded struct {
+	vars     foldedKeys
+	lookuper foldedKeys
+	dotenv   foldedKeys
+
+	environ foldedKeys
+}
+
+// foldedKeys maps upper cased names to the name they are stored under. When
+// names differ only in case the first in sorted order is used.
+type foldedKeys map[string]string
+
+func foldKeys(keys []string) foldedKeys {
+	sort.Strings(keys)
+
+	f := foldedKeys{}
+	for _, key := range keys {
+		upper := strings.ToUpper(key)
+		if _, ok := f[upper]; !ok {
+			f[upper] = key
+		}
+	}
+	return f
+}
+
+func (f foldedKeys) key(key string) (string, bool) {
+	actual, ok := f[strings.ToUpper(key)]
+	return actual, ok && actual != key
+}
+
+// lookupFolded looks up key in m, preferring an exact match.
+func lookupFolded(m map[string]string, f foldedKeys, key string) (string, bool) {
+	if value, ok := m[key]; ok {
+		return value, true
+	}
+	if actual, ok := f.key(key); ok {
+		value, ok := m[actual]
+		return value, ok
+	}
+	return "", false
+}
+
+func (o *Options) fold() {
+	if !o.CaseInsensitive {
+		return
+	}
+	o.snapshot()
+
+	o.folded = folded{
+		vars:   foldKeys(mapKeysOf(o.Vars)),
+		dotenv: foldKeys(mapKeysOf(o.dotenv)),
+	}
+
+	switch {
+	case o.Lookuper != nil:
+		o.folded.lookuper = foldKeys(keys(o.Lookuper))
+
+	case !o.Hermetic:
+		o.folded.environ = foldKeys(mapKeysOf(o.environ))
+	}
+}
+
+func mapKeysOf(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,92 @@
+package env
+
+import (
+	"testing"
+)
+
+func TestCaseInsensitive(t *testing.T) {
+	type config struct {
+		Host string `env:"FOLD_HOST"`
+		Port string `env:"FOLD_PORT,optional"`
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		opts []Option
+		want config
+		err  bool
+	}{
+		{
+			name: "environment",
+			env:  map[string]string{"fold_host": "lower", "Fold_Port": "mixed"},
+			want: config{Host: "lower", Port: "mixed"},
+		},
+		{
+			name: "exact match preferred",
+			env:  map[string]string{"FOLD_HOST": "exact", "fold_host": "lower"},
+			want: config{Host: "exact"},
+		},
+		{
+			name: "vars",
+			opts: []Option{WithVars(map[string]string{"fold_host": "vars"})},
+			want: config{Host: "vars"},
+		},
+		{
+			name: "lookuper",
+			opts: []Option{WithLookuper(Map{"Fold_Host": "map"})},
+			want: config{Host: "map"},
+		},
+		{
+			name: "first in sorted order",
+			opts: []Option{WithLookuper(Map{"fold_host": "b", "Fold_host": "a"})},
+			want: config{Host: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "FOLD_HOST", "fold_host", "FOLD_PORT", "Fold_Port")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var cfg config
+			err := ParseWithOptions(&cfg, append([]Option{WithCaseInsensitive()}, tt.opts...)...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+
+	t.Run("case sensitive by default", func(t *testing.T) {
+		var cfg config
+		if err := ParseFromMap(&cfg, map[string]string{"fold_host": "lower"}); err == nil {
+			t.Errorf("expected FOLD_HOST to be missing, found %+v", cfg)
+		}
+	})
+}
+
+func TestFoldKeys(t *testing.T) {
+	f := foldKeys([]string{"b_KEY", "A_key", "a_KEY"})
+
+	tests := []struct {
+		key    string
+		actual string
+		ok     bool
+	}{
+		{key: "A_KEY", actual: "A_key", ok: true},
+		{key: "A_key", actual: "A_key"},
+		{key: "B_KEY", actual: "b_KEY", ok: true},
+		{key: "C_KEY"},
+	}
+
+	for _, tt := range tests {
+		if actual, ok := f.key(tt.key); actual != tt.actual || ok != tt.ok {
+			t.Errorf("expected '%s', %v for '%s', found '%s', %v", tt.actual, tt.ok, tt.key, actual, ok)
+		}
+	}
+}
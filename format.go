@@ -0,0 +1,126 @@
+package env
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// formatValue is the inverse of setField, producing the string a variable
+// would need to hold for the field to get its current value.
+func (o Options) formatValue(v reflect.Value, tag Tag) (string, error) {
+	if tag.JSON {
+		raw, err := json.Marshal(v.Interface())
+		return string(raw), err
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		if v.Type().Implements(textMarshalerType) && tag.Layout == "" {
+			return marshalText(v)
+		}
+		return o.formatValue(v.Elem(), tag)
+	}
+
+	if v.Type() == durationType {
+		return v.Interface().(fmt.Stringer).String(), nil
+	}
+
+	if v.Type() == timeType && tag.Layout != "" {
+		return v.Interface().(time.Time).Format(timeLayout(tag.Layout)), nil
+	}
+
+	if value, ok := formatBuiltin(v); ok {
+		return value, nil
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		return marshalText(v)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		return marshalText(v.Addr())
+	}
+	if !v.CanAddr() && v.CanInterface() && reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+		// types such as big.Int marshal through a pointer
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return marshalText(ptr)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+
+	case reflect.Slice, reflect.Array:
+		if isByteSlice(v.Type()) {
+			return encodeBytes(v.Bytes(), tag.Encoding), nil
+		}
+		if isByteArray(v.Type()) {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return encodeBytes(b, tag.Encoding), nil
+		}
+
+		values := make([]string, v.Len())
+		for i := range values {
+			value, err := o.formatValue(v.Index(i), tag)
+			if err != nil {
+				return "", err
+			}
+			values[i] = quoteElement(value, o.separator(tag), tag.NoTrim)
+		}
+		return strings.Join(values, o.separator(tag)), nil
+
+	case reflect.Map:
+		kvSep := tag.KeyValueSeparator
+		if kvSep == "" {
+			kvSep = "="
+		}
+
+		pairs := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := o.formatValue(iter.Key(), tag)
+			if err != nil {
+				return "", err
+			}
+			value, err := o.formatValue(iter.Value(), tag)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, quoteElement(key+kvSep+value, o.separator(tag), tag.NoTrim))
+		}
+
+		// map iteration order is random, keep output stable
+		sort.Strings(pairs)
+		return strings.Join(pairs, o.separator(tag)), nil
+	}
+
+	return "", ErrUnsupported
+}
+
+func marshalText(v reflect.Value) (string, error) {
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	return string(text), err
+}
@@ -0,0 +1,129 @@
+// Package gcpsecrets resolves variables from Google Cloud Secret Manager.
+//
+// The package does not depend on the Google Cloud SDK. Callers pass a small
+// client, usually a thin wrapper around the SDK's client, which authenticates
+// with Application Default Credentials when created with NewClient:
+//
+//	type smClient struct{ c *secretmanager.Client }
+//
+//	func (s smClient) AccessSecretVersion(ctx context.Context, name string) (string, error) {
+//		out, err := s.c.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+//		if status.Code(err) == codes.NotFound {
+//			return "", gcpsecrets.ErrNotFound
+//		}
+//		if err != nil {
+//			return "", err
+//		}
+//		return string(out.Payload.Data), nil
+//	}
+package gcpsecrets
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reverted/env"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Client interface {
+	// AccessSecretVersion returns the payload of the secret version with the
+	// given resource name, projects/<project>/secrets/<secret>/versions/<version>.
+	AccessSecretVersion(ctx context.Context, name string) (string, error)
+}
+
+type options struct {
+	prefix   string
+	mapName  func(key string) string
+	version  string
+	versions map[string]string
+	ttl      time.Duration
+}
+
+type Option func(*options)
+
+// WithPrefix is prepended to the mapped secret name, e.g. "myapp-prod-".
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithNameMapper transforms a variable name before the prefix is applied.
+func WithNameMapper(fn func(key string) string) Option {
+	return func(o *options) {
+		o.mapName = fn
+	}
+}
+
+// WithVersion sets the version read for every secret, defaults to "latest".
+func WithVersion(version string) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithPinnedVersion pins the secret read for the variable key to version.
+func WithPinnedVersion(key, version string) Option {
+	return func(o *options) {
+		if o.versions == nil {
+			o.versions = map[string]string{}
+		}
+		o.versions[key] = version
+	}
+}
+
+// WithTTL sets how long values are cached, defaults to five minutes.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+func New(client Client, project string, opts ...Option) env.ContextLookuper {
+	o := options{version: "latest", ttl: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return env.Cache(&source{client: client, project: project, opts: o}, o.ttl)
+}
+
+type source struct {
+	client  Client
+	project string
+	opts    options
+}
+
+func (s *source) Lookup(key string) (string, bool) {
+	value, ok, _ := s.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (s *source) SourceName() string {
+	return "gcp-secretmanager"
+}
+
+func (s *source) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	name := key
+	if s.opts.mapName != nil {
+		name = s.opts.mapName(name)
+	}
+
+	version := s.opts.version
+	if pinned, ok := s.opts.versions[key]; ok {
+		version = pinned
+	}
+
+	value, err := s.client.AccessSecretVersion(ctx, "projects/"+s.project+"/secrets/"+s.opts.prefix+name+"/versions/"+version)
+	if errors.Is(err, ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
@@ -0,0 +1,94 @@
+package gcpsecrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/reverted/env"
+)
+
+type fakeClient struct {
+	values map[string]string
+	err    error
+	calls  []string
+}
+
+func (c *fakeClient) AccessSecretVersion(ctx context.Context, name string) (string, error) {
+	c.calls = append(c.calls, name)
+	if c.err != nil {
+		return "", c.err
+	}
+	value, ok := c.values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func TestSource(t *testing.T) {
+	values := map[string]string{
+		"projects/p/secrets/DB_PASSWORD/versions/latest":       "latest",
+		"projects/p/secrets/DB_PASSWORD/versions/3":            "v3",
+		"projects/p/secrets/myapp-db-password/versions/latest": "mapped",
+		"projects/p/secrets/API_KEY/versions/2":                "pinned",
+		"projects/p/secrets/myapp-api-key/versions/7":          "mapped pinned",
+	}
+	lower := func(key string) string { return strings.ToLower(strings.ReplaceAll(key, "_", "-")) }
+
+	tests := []struct {
+		name string
+		opts []Option
+		key  string
+		want string
+		ok   bool
+		call string
+	}{
+		{name: "latest", key: "DB_PASSWORD", want: "latest", ok: true, call: "projects/p/secrets/DB_PASSWORD/versions/latest"},
+		{name: "version", opts: []Option{WithVersion("3")}, key: "DB_PASSWORD", want: "v3", ok: true, call: "projects/p/secrets/DB_PASSWORD/versions/3"},
+		{name: "prefix and mapper", opts: []Option{WithPrefix("myapp-"), WithNameMapper(lower)}, key: "DB_PASSWORD", want: "mapped", ok: true, call: "projects/p/secrets/myapp-db-password/versions/latest"},
+		{name: "pinned", opts: []Option{WithPinnedVersion("API_KEY", "2")}, key: "API_KEY", want: "pinned", ok: true, call: "projects/p/secrets/API_KEY/versions/2"},
+		{name: "pinned by variable name", opts: []Option{WithPrefix("myapp-"), WithNameMapper(lower), WithPinnedVersion("API_KEY", "7")}, key: "API_KEY", want: "mapped pinned", ok: true, call: "projects/p/secrets/myapp-api-key/versions/7"},
+		{name: "not found", key: "MISSING", call: "projects/p/secrets/MISSING/versions/latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{values: values}
+			l := New(client, "p", tt.opts...)
+
+			for i := 0; i < 2; i++ {
+				value, ok, err := l.LookupContext(context.Background(), tt.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if value != tt.want || ok != tt.ok {
+					t.Errorf("expected '%s', %v, found '%s', %v", tt.want, tt.ok, value, ok)
+				}
+			}
+
+			// the second lookup is served from the cache
+			if len(client.calls) != 1 || client.calls[0] != tt.call {
+				t.Errorf("expected a single call for '%s', found %v", tt.call, client.calls)
+			}
+		})
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	errDenied := errors.New("permission denied")
+	l := New(&fakeClient{err: errDenied}, "p")
+
+	var cfg struct {
+		Password string `env:"DB_PASSWORD"`
+	}
+	err := env.ParseWithOptions(&cfg, env.WithLookuper(l))
+	if !errors.Is(err, errDenied) || !errors.Is(err, env.ErrLookup) {
+		t.Errorf("expected a lookup error wrapping %v, found %v", errDenied, err)
+	}
+
+	if name := l.(env.NamedLookuper).SourceName(); name != "gcp-secretmanager" {
+		t.Errorf("expected source 'gcp-secretmanager', found '%s'", name)
+	}
+}
@@ -0,0 +1,15 @@
+package env
+
+func ParseAs[T any](opts ...Option) (T, error) {
+	var obj T
+	err := ParseWithOptions(&obj, opts...)
+	return obj, err
+}
+
+func MustParseAs[T any](opts ...Option) T {
+	obj, err := ParseAs[T](opts...)
+	if err != nil {
+		panic(diagnostics(err))
+	}
+	return obj
+}
@@ -0,0 +1,56 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAs(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=80"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want config
+		err  bool
+	}{
+		{name: "set", vars: Map{"HOST": "h", "PORT": "8080"}, want: config{Host: "h", Port: 8080}},
+		{name: "default", vars: Map{"HOST": "h"}, want: config{Host: "h", Port: 80}},
+		{name: "missing", vars: Map{}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseAs[config](WithLookuper(tt.vars), WithHermetic())
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestMustParseAs(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+	}
+
+	cfg := MustParseAs[config](WithLookuper(Map{"HOST": "h"}), WithHermetic())
+	if cfg.Host != "h" {
+		t.Errorf("expected 'h', found '%s'", cfg.Host)
+	}
+
+	defer func() {
+		r := recover()
+		if msg, ok := r.(string); !ok || !strings.Contains(msg, "HOST") {
+			t.Errorf("expected a panic mentioning HOST, found %v", r)
+		}
+	}()
+	MustParseAs[config](WithLookuper(Map{}), WithHermetic())
+}
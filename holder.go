@@ -0,0 +1,88 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Holder holds a parsed configuration that can be reloaded while other
+// goroutines read it. Each reload parses into a new value and swaps it in, so
+// readers see either the old or the new configuration, never a mix.
+type Holder[T any] struct {
+	opts []Option
+
+	// mu serializes reloads, Load does not take it.
+	mu      sync.Mutex
+	current atomic.Pointer[T]
+
+	// secrets holds the paths of the fields of current whose values were
+	// secret, such as those read from files or decrypted, which are left
+	// out of diffs.
+	secrets map[string]bool
+}
+
+// NewHolder parses T with opts and returns a Holder for it.
+func NewHolder[T any](opts ...Option) (*Holder[T], error) {
+	h := &Holder[T]{opts: opts}
+	if err := h.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Load returns the current configuration, which must not be modified.
+func (h *Holder[T]) Load() *T {
+	return h.current.Load()
+}
+
+// Reload parses a new configuration and swaps it in. On error the current
+// configuration is kept.
+func (h *Holder[T]) Reload(ctx context.Context) error {
+	_, err := h.reload(ctx)
+	return err
+}
+
+// reload swaps in a new configuration and returns how it differs from the
+// previous one.
+func (h *Holder[T]) reload(ctx context.Context) (Diff, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var report Report
+	opts := newOptions(h.opts)
+	opts.report = &report
+
+	next := new(T)
+	if err := parse(ctx, next, opts); err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]bool{}
+	for _, f := range report {
+		if f.Secret {
+			secrets[f.Field] = true
+		}
+	}
+
+	var diff Diff
+	if current := h.current.Load(); current != nil {
+		// a value is hidden if it was secret in either configuration
+		hidden := map[string]bool{}
+		for _, m := range []map[string]bool{h.secrets, secrets} {
+			for path := range m {
+				hidden[path] = true
+			}
+		}
+
+		var err error
+		if diff, err = diffValues(reflect.ValueOf(current).Elem(), reflect.ValueOf(next).Elem(), opts, hidden); err != nil {
+			return nil, err
+		}
+	}
+
+	h.current.Store(next)
+	h.secrets = secrets
+	return diff, nil
+}
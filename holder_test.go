@@ -0,0 +1,94 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestHolder(t *testing.T) {
+	vars := &watchVars{vars: map[string]string{"HOST": "localhost", "PORT": "80"}}
+	h, err := NewHolder[watchConfig](WithLookuper(vars), WithHermetic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		port string
+		want watchConfig
+		err  bool
+	}{
+		{name: "unchanged", port: "80", want: watchConfig{Host: "localhost", Port: 80}},
+		{name: "changed", port: "8080", want: watchConfig{Host: "localhost", Port: 8080}},
+		{name: "invalid keeps current", port: "invalid", want: watchConfig{Host: "localhost", Port: 8080}, err: true},
+		{name: "recovered", port: "443", want: watchConfig{Host: "localhost", Port: 443}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := h.Load()
+			vars.set("PORT", tt.port)
+
+			err := h.Reload(context.Background())
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+
+			got := h.Load()
+			if *got != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, *got)
+			}
+			if tt.err && got != before {
+				t.Errorf("expected the failed reload to keep the current pointer")
+			}
+			if !tt.err && got == before {
+				t.Errorf("expected the reload to swap in a new value")
+			}
+		})
+	}
+}
+
+func TestNewHolderError(t *testing.T) {
+	_, err := NewHolder[watchConfig](WithLookuper(Map{"HOST": "localhost", "PORT": "invalid"}), WithHermetic())
+
+	var parseErr ParseError
+	if !errors.As(err, &parseErr) {
+		t.Errorf("expected a ParseError, found %v", err)
+	}
+}
+
+func TestHolderConcurrent(t *testing.T) {
+	vars := &watchVars{vars: map[string]string{"HOST": "localhost", "PORT": "0"}}
+	h, err := NewHolder[watchConfig](WithLookuper(vars), WithHermetic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				vars.set("PORT", strconv.Itoa(i*100+j))
+				if err := h.Reload(context.Background()); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if cfg := h.Load(); cfg.Host != "localhost" {
+					t.Errorf("unexpected config %+v", *cfg)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
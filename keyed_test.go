@@ -0,0 +1,157 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+type keyedServer struct {
+	Host   string `env:"HOST,optional"`
+	DBHost string `env:"DB_HOST,optional"`
+	Port   int    `env:"PORT,optional"`
+}
+
+func TestParseKeyed(t *testing.T) {
+	type config struct {
+		Servers map[string]keyedServer `envPrefix:"APP_"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want map[string]keyedServer
+	}{
+		{
+			name: "none",
+			vars: Map{"OTHER": "x"},
+		},
+		{
+			name: "keys",
+			vars: Map{"APP_A_HOST": "a", "APP_B_PORT": "2"},
+			want: map[string]keyedServer{"A": {Host: "a"}, "B": {Port: 2}},
+		},
+		{
+			name: "longest name",
+			vars: Map{"APP_ACME_DB_HOST": "db", "APP_ACME_HOST": "web"},
+			want: map[string]keyedServer{"ACME": {Host: "web", DBHost: "db"}},
+		},
+		{
+			name: "no phantom key from another key's name",
+			vars: Map{"APP_ACME_DB_HOST": "db", "APP_ACME_DB_PORT": "5432"},
+			want: map[string]keyedServer{"ACME": {DBHost: "db"}},
+		},
+		{
+			name: "keys with underscores",
+			vars: Map{"APP_EU_WEST_HOST": "eu", "APP_EU_WEST_DB_HOST": "eu-db"},
+			want: map[string]keyedServer{"EU_WEST": {Host: "eu", DBHost: "eu-db"}},
+		},
+		{
+			name: "name alone is not a key",
+			vars: Map{"APP_HOST": "x", "APP_DB_HOST": "y"},
+			want: map[string]keyedServer{"DB": {Host: "y"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg.Servers, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg.Servers)
+			}
+		})
+	}
+}
+
+func TestParseIndexed(t *testing.T) {
+	type upstream struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=80"`
+	}
+	type config struct {
+		Upstreams []upstream  `envPrefix:"UPSTREAM_"`
+		Pointers  []*upstream `envPrefix:"PTR_"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want config
+		err  bool
+	}{
+		{
+			name: "none",
+			vars: Map{"UPSTREAM_HOST": "x"},
+		},
+		{
+			name: "indexed",
+			vars: Map{"UPSTREAM_0_HOST": "a", "UPSTREAM_1_HOST": "b", "UPSTREAM_1_PORT": "8080"},
+			want: config{Upstreams: []upstream{{Host: "a", Port: 80}, {Host: "b", Port: 8080}}},
+		},
+		{
+			name: "stops at the first gap",
+			vars: Map{"UPSTREAM_0_HOST": "a", "UPSTREAM_2_HOST": "c"},
+			want: config{Upstreams: []upstream{{Host: "a", Port: 80}}},
+		},
+		{
+			name: "pointer elements",
+			vars: Map{"PTR_0_HOST": "a"},
+			want: config{Pointers: []*upstream{{Host: "a", Port: 80}}},
+		},
+		{
+			name: "element errors",
+			vars: Map{"UPSTREAM_0_PORT": "8080"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic())
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestParseIndexedCallbacks(t *testing.T) {
+	type upstream struct {
+		Host string `env:"HOST,optional"`
+		Port int    `env:"PORT,default=80"`
+	}
+	type config struct {
+		Upstreams []upstream `envPrefix:"UPSTREAM_"`
+		Backup    *upstream  `envPrefix:"BACKUP_"`
+	}
+
+	var set []string
+	var cfg config
+	report, err := ParseWithReport(&cfg, WithLookuper(Map{"UPSTREAM_0_HOST": "a"}), WithHermetic(),
+		WithOnSet(func(f FieldInfo, value, source string) {
+			set = append(set, f.Env)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"UPSTREAM_0_HOST", "UPSTREAM_0_PORT"}; !reflect.DeepEqual(set, want) {
+		t.Errorf("expected OnSet for %q, found %q", want, set)
+	}
+	var reported []string
+	for _, f := range report {
+		reported = append(reported, f.Env)
+	}
+	if want := []string{"UPSTREAM_0_HOST", "UPSTREAM_0_PORT"}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("expected reports for %q, found %q", want, reported)
+	}
+	if cfg.Backup != nil {
+		t.Errorf("expected the backup to be left nil, found %+v", cfg.Backup)
+	}
+}
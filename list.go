@@ -0,0 +1,82 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitList splits a slice or map value on sep. Elements are trimmed of
+// surrounding whitespace unless noTrim is set, and an element wrapped in
+// double quotes may contain the separator, with \" and \\ as escapes.
+func SplitList(value, sep string, noTrim bool) ([]string, error) {
+	var elems []string
+	for {
+		rest := value
+		if !noTrim {
+			rest = strings.TrimLeft(rest, " \t")
+		}
+
+		if !strings.HasPrefix(rest, `"`) {
+			elem, next, found := strings.Cut(value, sep)
+			if !noTrim {
+				elem = strings.TrimSpace(elem)
+			}
+			elems = append(elems, elem)
+
+			if !found {
+				return elems, nil
+			}
+			value = next
+			continue
+		}
+
+		elem, next, err := unquoteElement(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+
+		if !noTrim {
+			next = strings.TrimLeft(next, " \t")
+		}
+		if next == "" {
+			return elems, nil
+		}
+		if !strings.HasPrefix(next, sep) {
+			return nil, fmt.Errorf("unexpected characters after quoted element '%s'", elem)
+		}
+		value = next[len(sep):]
+	}
+}
+
+// unquoteElement reads a quoted element up to its closing quote, returning
+// the rest of the value.
+func unquoteElement(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return b.String(), s[i+1:], nil
+
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+			b.WriteByte(s[i+1])
+			i++
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated quoted element")
+}
+
+// quoteElement quotes elem when SplitList would not read it back as is.
+func quoteElement(elem, sep string, noTrim bool) string {
+	trimmed := noTrim || strings.TrimSpace(elem) == elem
+	if trimmed && !strings.Contains(elem, sep) && !strings.HasPrefix(elem, `"`) {
+		return elem
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(elem) + `"`
+}
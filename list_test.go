@@ -0,0 +1,124 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		value  string
+		sep    string
+		noTrim bool
+		want   []string
+		err    bool
+	}{
+		{value: "a,b,c", sep: ",", want: []string{"a", "b", "c"}},
+		{value: " alice , bob ", sep: ",", want: []string{"alice", "bob"}},
+		{value: " alice , bob ", sep: ",", noTrim: true, want: []string{" alice ", " bob "}},
+		{value: `"a,b",c`, sep: ",", want: []string{"a,b", "c"}},
+		{value: ` "a,b" , c`, sep: ",", want: []string{"a,b", "c"}},
+		{value: `"say \"hi\"","back\\slash"`, sep: ",", want: []string{`say "hi"`, `back\slash`}},
+		{value: `" padded "`, sep: ",", want: []string{" padded "}},
+		{value: `a;;b`, sep: ";;", want: []string{"a", "b"}},
+		{value: "a,", sep: ",", want: []string{"a", ""}},
+		{value: `a"b,c`, sep: ",", want: []string{`a"b`, "c"}},
+		{value: `"open,b`, sep: ",", err: true},
+		{value: `"a"b,c`, sep: ",", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitList(tt.value, tt.sep, tt.noTrim)
+		if (err != nil) != tt.err {
+			t.Errorf("unexpected error for %q : %v", tt.value, err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expected %q to split into %q, found %q", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestQuoteElement(t *testing.T) {
+	tests := []struct {
+		elem   string
+		noTrim bool
+	}{
+		{elem: "plain"},
+		{elem: "a,b"},
+		{elem: " padded "},
+		{elem: " padded ", noTrim: true},
+		{elem: `"quoted"`},
+		{elem: `back\slash,`},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitList(quoteElement(tt.elem, ",", tt.noTrim), ",", tt.noTrim)
+		if err != nil {
+			t.Errorf("unexpected error for %q : %v", tt.elem, err)
+			continue
+		}
+		if len(got) != 1 || got[0] != tt.elem {
+			t.Errorf("expected %q to read back, found %q", tt.elem, got)
+		}
+	}
+}
+
+func TestNoTrimTag(t *testing.T) {
+	type config struct {
+		Trimmed []string `env:"TRIMMED"`
+		Kept    []string `env:"KEPT,noTrim"`
+	}
+
+	var cfg config
+	if err := ParseFromMap(&cfg, map[string]string{"TRIMMED": " a , \"b,c\" ", "KEPT": " a , b "}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := config{Trimmed: []string{"a", "b,c"}, Kept: []string{" a ", " b "}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("expected %q, found %q", want, cfg)
+	}
+}
+
+func TestPathList(t *testing.T) {
+	type config struct {
+		Paths   []string  `env:"PATHS,pathlist"`
+		Cleaned []string  `env:"CLEANED,pathlist=clean"`
+		Abs     [1]string `env:"ABS,pathlist=abs"`
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sep := string(os.PathListSeparator)
+	vars := map[string]string{
+		"PATHS":   "a,b" + sep + "c",
+		"CLEANED": "a/../b/" + sep + "./c",
+		"ABS":     "bin/../lib",
+	}
+
+	var cfg config
+	if err := ParseFromMap(&cfg, vars); err != nil {
+		t.Fatal(err)
+	}
+
+	want := config{
+		Paths:   []string{"a,b", "c"},
+		Cleaned: []string{"b", "c"},
+		Abs:     [1]string{filepath.Join(wd, "lib")},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("expected %q, found %q", want, cfg)
+	}
+
+	for _, tag := range []string{`env:"P,pathlist=rel"`, `env:"P,pathlist,separator=;"`} {
+		if _, _, err := ParseTag(reflect.StructTag(tag)); err == nil {
+			t.Errorf("expected an error for %s", tag)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package env
+
+import (
+	"reflect"
+)
+
+// Logger receives a debug trace of how every field is resolved, with args
+// alternating keys and values. *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+}
+
+// trace logs the names tried for a field and the source and value it got,
+// or why it failed. Secret values and values read from files are redacted.
+func (d *decoder) trace(path string, tag Tag, env, source string, v reflect.Value, err error) {
+	var msg string
+	var args []interface{}
+	switch {
+	case err != nil:
+		msg, args = "env field failed", []interface{}{"field", path, "tried", tag.Names(), "error", err}
+
+	case source == "":
+		msg, args = "env field not set", []interface{}{"field", path, "tried", tag.Names()}
+
+	default:
+		value, formatErr := d.opts.formatValue(v, tag)
+		if formatErr != nil {
+			value = "<" + v.Type().String() + ">"
+		}
+		if isSecret(tag, source) {
+			value = redact(value)
+		}
+		msg, args = "env field resolved", []interface{}{"field", path, "tried", tag.Names(), "env", env, "source", source, "value", value}
+	}
+
+	d.emit(func() { d.opts.Logger.Debug(msg, args...) })
+}
@@ -0,0 +1,70 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) {
+	line := fmt.Sprintln(append([]interface{}{msg}, args...)...)
+	l.lines = append(l.lines, strings.TrimSuffix(line, "\n"))
+}
+
+func TestWithLogger(t *testing.T) {
+	type config struct {
+		Host     string `env:"HOST"`
+		Password string `env:"PASSWORD,secret"`
+		Token    string `env:"TOKEN"`
+		Debug    bool   `env:"DEBUG,optional"`
+		Port     int    `env:"PORT,optional"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want []string
+	}{
+		{
+			name: "resolved",
+			vars: Map{"HOST": "localhost", "PASSWORD": "hunter2", "TOKEN_FILE": "/token"},
+			want: []string{
+				"env field resolved field Host tried [HOST] env HOST source lookuper value localhost",
+				"env field resolved field Password tried [PASSWORD] env PASSWORD source lookuper value ******",
+				"env field resolved field Token tried [TOKEN] env TOKEN source file value ******",
+				"env field not set field Debug tried [DEBUG]",
+				"env field not set field Port tried [PORT]",
+			},
+		},
+		{
+			name: "failed",
+			vars: Map{"HOST": "localhost", "PASSWORD": "hunter2", "TOKEN": "t", "PORT": "x"},
+			want: []string{
+				"env field resolved field Host tried [HOST] env HOST source lookuper value localhost",
+				"env field resolved field Password tried [PASSWORD] env PASSWORD source lookuper value ******",
+				"env field resolved field Token tried [TOKEN] env TOKEN source lookuper value t",
+				"env field not set field Debug tried [DEBUG]",
+				`env field failed field Port tried [PORT] error error parsing env 'PORT' for field 'Port' : strconv.ParseInt: parsing "x": invalid syntax`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l recordLogger
+			var cfg config
+			_ = ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic(), WithLogger(&l),
+				WithFS(fstest.MapFS{"token": {Data: []byte("token value")}}), WithFileFallback())
+
+			if !reflect.DeepEqual(l.lines, tt.want) {
+				t.Errorf("expected\n%q\nfound\n%q", tt.want, l.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package env
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+type Lookuper interface {
+	Lookup(key string) (string, bool)
+}
+
+// ContextLookuper is implemented by sources that can fail or block, such as
+// remote secret stores. It is used in preference to Lookup and its errors are
+// reported instead of the variable being treated as unset.
+type ContextLookuper interface {
+	Lookuper
+	LookupContext(ctx context.Context, key string) (string, bool, error)
+}
+
+func lookupContext(ctx context.Context, l Lookuper, key string) (string, bool, error) {
+	if cl, ok := l.(ContextLookuper); ok {
+		return cl.LookupContext(ctx, key)
+	}
+
+	value, ok := l.Lookup(key)
+	return value, ok, nil
+}
+
+type LookupFunc func(key string) (string, bool)
+
+func (f LookupFunc) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+type Map map[string]string
+
+func (m Map) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func (m Map) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// NamedLookuper is implemented by sources that want to be identified in
+// reports and OnSet callbacks, which otherwise show SourceLookuper.
+type NamedLookuper interface {
+	Lookuper
+	SourceName() string
+}
+
+type named struct {
+	name string
+	l    Lookuper
+}
+
+func Named(name string, l Lookuper) Lookuper {
+	return named{name: name, l: l}
+}
+
+func (n named) Lookup(key string) (string, bool) {
+	return n.l.Lookup(key)
+}
+
+func (n named) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	return lookupContext(ctx, n.l, key)
+}
+
+func (n named) Keys() []string {
+	return keys(n.l)
+}
+
+func (n named) SourceName() string {
+	return n.name
+}
+
+var OS Lookuper = Named(SourceEnv, environ{})
+
+type environ struct{}
+
+func (environ) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (environ) Keys() []string {
+	return environKeys(os.Environ())
+}
+
+// snapshot reads the operating system environment once, so every field is
+// resolved from the same state even if it is changed during parsing.
+func (o *Options) snapshot() {
+	if o.environ != nil || o.Lookuper != nil || o.Hermetic {
+		return
+	}
+	o.environ = parseEnviron(os.Environ())
+}
+
+func environKeys(environ []string) []string {
+	keys := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if key, _, _ := strings.Cut(kv, "="); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// KeyLister is implemented by sources that can enumerate their variables,
+// which strict mode needs to find unknown ones.
+type KeyLister interface {
+	Keys() []string
+}
+
+func keys(l Lookuper) []string {
+	if kl, ok := l.(KeyLister); ok {
+		return kl.Keys()
+	}
+	return nil
+}
+
+func sourceName(l Lookuper) string {
+	if n, ok := l.(NamedLookuper); ok {
+		return n.SourceName()
+	}
+	return SourceLookuper
+}
+
+// lookupNamed looks up key and names the source that supplied it, descending
+// into Sources so the source that actually matched is reported. A non-empty
+// from skips every source not named from.
+func lookupNamed(ctx context.Context, l Lookuper, key, from string) (string, string, bool, error) {
+	if s, ok := l.(sources); ok {
+		for _, l := range s {
+			value, source, ok, err := lookupNamed(ctx, l, key, from)
+			if err != nil || ok {
+				return value, source, ok, err
+			}
+		}
+		return "", "", false, nil
+	}
+
+	if from != "" && sourceName(l) != from {
+		return "", "", false, nil
+	}
+
+	value, ok, err := lookupContext(ctx, l, key)
+	return value, sourceName(l), ok, err
+}
+
+// Sources reported for resolved values.
+const (
+	SourceFlag     = "flag"
+	SourceEnv      = "env"
+	SourceVars     = "vars"
+	SourceLookuper = "lookuper"
+	SourceDefault  = "default"
+	SourceExisting = "existing"
+	SourceFile     = "file"
+	SourceDotenv   = "dotenv"
+)
+
+func (o Options) lookup(ctx context.Context, key string) (string, string, bool, error) {
+	return o.lookupFrom(ctx, key, "")
+}
+
+// lookupFrom looks key up in the source named from only, or in every source
+// if from is empty. Flags match SourceFlag only, whatever their own name.
+func (o Options) lookupFrom(ctx context.Context, key, from string) (string, string, bool, error) {
+	if o.Flags != nil && (from == "" || from == SourceFlag) {
+		value, source, ok, err := lookupNamed(ctx, o.Flags, strings.TrimPrefix(key, o.Prefix), "")
+		if err != nil || ok {
+			return value, source, ok, err
+		}
+	}
+
+	if from == "" || from == SourceVars {
+		if value, ok := lookupFolded(o.Vars, o.folded.vars, key); ok {
+			return value, SourceVars, true, nil
+		}
+	}
+
+	switch {
+	case o.Lookuper != nil:
+		value, source, ok, err := lookupNamed(ctx, o.Lookuper, key, from)
+		if actual, folded := o.folded.lookuper.key(key); err == nil && !ok && folded {
+			value, source, ok, err = lookupNamed(ctx, o.Lookuper, actual, from)
+		}
+		if err != nil {
+			return "", source, false, err
+		}
+		if ok {
+			return value, source, true, nil
+		}
+
+	case from != "" && from != SourceEnv:
+		// the process environment is not the requested source
+
+	case o.environ != nil && !o.Hermetic:
+		if value, ok := lookupFolded(o.environ, o.folded.environ, key); ok {
+			return value, SourceEnv, true, nil
+		}
+
+	case !o.Hermetic:
+		if value, ok := os.LookupEnv(key); ok {
+			return value, SourceEnv, true, nil
+		}
+	}
+
+	if from == "" || from == SourceDotenv {
+		if value, ok := lookupFolded(o.dotenv, o.folded.dotenv, key); ok {
+			return value, SourceDotenv, true, nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+type sources []Lookuper
+
+func Sources(ls ...Lookuper) Lookuper {
+	return sources(ls)
+}
+
+func (s sources) Lookup(key string) (string, bool) {
+	value, ok, _ := s.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (s sources) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	for _, l := range s {
+		value, ok, err := lookupContext(ctx, l, key)
+		if err != nil || ok {
+			return value, ok, err
+		}
+	}
+	return "", false, nil
+}
+
+func (s sources) Keys() []string {
+	var all []string
+	for _, l := range s {
+		all = append(all, keys(l)...)
+	}
+	return all
+}
+
+// keys lists the variables visible to parsing from every source that can
+// enumerate them.
+func (o Options) keys() []string {
+	var all []string
+	for key := range o.Vars {
+		all = append(all, key)
+	}
+
+	switch {
+	case o.Lookuper != nil:
+		all = append(all, keys(o.Lookuper)...)
+
+	case o.environ != nil:
+		all = append(all, mapKeysOf(o.environ)...)
+
+	case !o.Hermetic:
+		all = append(all, keys(environ{})...)
+	}
+
+	for key := range o.dotenv {
+		all = append(all, key)
+	}
+
+	return all
+}
@@ -0,0 +1,184 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSources(t *testing.T) {
+	env := Named("env", Map{"HOST": "env"})
+	dotenv := Named("dotenv", Map{"HOST": "dotenv", "PORT": "8080"})
+	defaults := Map{"HOST": "default", "PORT": "80", "NAME": "app"}
+
+	tests := []struct {
+		name   string
+		l      Lookuper
+		key    string
+		want   string
+		ok     bool
+		source string
+	}{
+		{name: "first source wins", l: Sources(env, dotenv, defaults), key: "HOST", want: "env", ok: true, source: "env"},
+		{name: "falls through", l: Sources(env, dotenv, defaults), key: "PORT", want: "8080", ok: true, source: "dotenv"},
+		{name: "unnamed source", l: Sources(env, dotenv, defaults), key: "NAME", want: "app", ok: true, source: SourceLookuper},
+		{name: "missing", l: Sources(env, dotenv, defaults), key: "MISSING"},
+		{name: "nested", l: Sources(Sources(env), Sources(dotenv)), key: "PORT", want: "8080", ok: true, source: "dotenv"},
+		{name: "empty", l: Sources(), key: "HOST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := tt.l.Lookup(tt.key)
+			if value != tt.want || ok != tt.ok {
+				t.Errorf("expected '%s', %v, found '%s', %v", tt.want, tt.ok, value, ok)
+			}
+
+			_, source, _, err := lookupNamed(context.Background(), tt.l, tt.key, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok && source != tt.source {
+				t.Errorf("expected source '%s', found '%s'", tt.source, source)
+			}
+		})
+	}
+}
+
+func TestSourcesErrors(t *testing.T) {
+	failing := &conditionLookuper{vars: Map{}, fail: map[string]bool{"HOST": true}}
+
+	type config struct {
+		Host string `env:"HOST"`
+	}
+
+	// an error stops the cascade rather than falling through to the next
+	// source
+	var cfg config
+	err := ParseWithOptions(&cfg, WithLookuper(Sources(failing, Map{"HOST": "fallback"})))
+	if !errors.As(err, new(LookupError)) {
+		t.Errorf("expected a LookupError, found %v", err)
+	}
+}
+
+func TestSourcesKeys(t *testing.T) {
+	got := Sources(Map{"A": "1"}, LookupFunc(func(string) (string, bool) { return "", false }), Map{"B": "2"}).(KeyLister).Keys()
+	if want := []string{"A", "B"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, found %v", want, got)
+	}
+}
+
+// contextLookuper fails lookups once its context is done and records the
+// contexts it was called with.
+type contextLookuper struct {
+	vars Map
+	ctxs []context.Context
+}
+
+func (l *contextLookuper) Lookup(key string) (string, bool) {
+	value, ok, _ := l.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (l *contextLookuper) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	l.ctxs = append(l.ctxs, ctx)
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	value, ok := l.vars[key]
+	return value, ok, nil
+}
+
+func TestParseContext(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+	}
+
+	type ctxKey struct{}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{name: "background", ctx: context.Background()},
+		{name: "values are passed through", ctx: context.WithValue(context.Background(), ctxKey{}, "v")},
+		{name: "canceled", ctx: canceled, err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &contextLookuper{vars: Map{"HOST": "h"}}
+
+			var cfg config
+			err := ParseContext(tt.ctx, &cfg, WithLookuper(l))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, found %v", tt.err, err)
+			}
+			if tt.err == nil && cfg.Host != "h" {
+				t.Errorf("expected 'h', found '%s'", cfg.Host)
+			}
+
+			for _, ctx := range l.ctxs {
+				if ctx.Value(ctxKey{}) != tt.ctx.Value(ctxKey{}) {
+					t.Errorf("expected the context to be passed to the lookuper")
+				}
+			}
+		})
+	}
+}
+
+func TestFromTag(t *testing.T) {
+	t.Setenv("FROM_V", "env")
+
+	fsys := fstest.MapFS{".env": {Data: []byte("FROM_V=dotenv\n")}}
+	all := []Option{
+		WithFlags(Map{"FROM_V": "flag"}),
+		WithVars(map[string]string{"FROM_V": "vars"}),
+		WithFS(fsys), WithDotenv(".env"),
+	}
+	lookuper := WithLookuper(Sources(Named("remote", Map{"FROM_V": "remote"}), Map{"FROM_V": "lookuper"}))
+
+	tests := []struct {
+		name string
+		tag  reflect.StructTag
+		opts []Option
+		want string
+		err  bool
+	}{
+		{name: "any", tag: `env:"FROM_V"`, opts: all, want: "flag"},
+		{name: "flag", tag: `env:"FROM_V,from=flag"`, opts: all, want: "flag"},
+		{name: "vars", tag: `env:"FROM_V,from=vars"`, opts: all, want: "vars"},
+		{name: "env", tag: `env:"FROM_V,from=env"`, opts: all, want: "env"},
+		{name: "dotenv", tag: `env:"FROM_V,from=dotenv"`, opts: all, want: "dotenv"},
+		{name: "named lookuper", tag: `env:"FROM_V,from=remote"`, opts: append(all, lookuper), want: "remote"},
+		{name: "unnamed lookuper", tag: `env:"FROM_V,from=lookuper"`, opts: append(all, lookuper), want: "lookuper"},
+		{name: "env replaced by lookuper", tag: `env:"FROM_V,from=env"`, opts: append(all, lookuper), err: true},
+		{name: "unknown source", tag: `env:"FROM_V,from=vault"`, opts: all, err: true},
+		{name: "default", tag: `env:"FROM_V,from=vault,default=x"`, opts: all, want: "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(""), Tag: tt.tag}})
+			v := reflect.New(typ)
+
+			err := ParseWithOptions(v.Interface(), tt.opts...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if got := v.Elem().Field(0).String(); err == nil && got != tt.want {
+				t.Errorf("expected %q, found %q", tt.want, got)
+			}
+		})
+	}
+
+	if _, _, err := ParseTag(`env:"FROM_V,from="`); err == nil {
+		t.Error("expected an error for an empty source")
+	}
+}
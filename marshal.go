@@ -0,0 +1,164 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Marshal serializes obj back into variables that would parse to its current
+// values. Nil pointer fields are left out.
+func Marshal(obj interface{}, opts ...Option) (map[string]string, error) {
+	kvs, err := marshal(obj, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		m[kv[0]] = kv[1]
+	}
+	return m, nil
+}
+
+// MarshalWriter writes the variables of obj as dotenv lines in field order,
+// quoting values where needed so ReadDotenv reads them back unchanged.
+func MarshalWriter(obj interface{}, w io.Writer, opts ...Option) error {
+	kvs, err := marshal(obj, newOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range kvs {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", kv[0], quoteDotenv(kv[1])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteShell writes the variables of obj as export statements that a POSIX
+// shell can source, for wrapper scripts.
+func WriteShell(w io.Writer, obj interface{}, opts ...Option) error {
+	kvs, err := marshal(obj, newOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range kvs {
+		if _, err := fmt.Fprintf(w, "export %s=%s\n", kv[0], quoteShell(kv[1])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteSystemd writes the variables of obj in the format of the files read by
+// the EnvironmentFile directive of systemd units.
+func WriteSystemd(w io.Writer, obj interface{}, opts ...Option) error {
+	kvs, err := marshal(obj, newOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range kvs {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", kv[0], quoteSystemd(kv[1])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quoteShell single quotes value unless it only holds characters no shell
+// treats specially. Single quotes inside are closed, escaped and reopened.
+func quoteShell(value string) string {
+	safe := value != ""
+	for _, c := range value {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_@%+=:,./-", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// quoteSystemd double quotes value where needed. systemd only unescapes
+// backslashes before quotes, backslashes, dollars and backticks and keeps
+// newlines inside quotes as they are.
+func quoteSystemd(value string) string {
+	if value == "" || !strings.ContainsAny(value, " \t\n\r#;\"'\\$`") {
+		return value
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+	return `"` + r.Replace(value) + `"`
+}
+
+func marshal(obj interface{}, opts Options) ([][2]string, error) {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() || !isStruct(v.Type()) {
+		return nil, fmt.Errorf("cannot marshal '%T' : expected a struct", obj)
+	}
+
+	var kvs [][2]string
+
+	err := walk(v, opts, func(f fieldInfo) error {
+		if f.value.Kind() == reflect.Ptr && f.value.IsNil() {
+			return nil
+		}
+
+		value, err := opts.formatValue(f.value, f.tag)
+		if errors.Is(err, ErrUnsupported) {
+			return UnsupportedError{Env: f.tag.Env, Field: f.path, Type: f.field.Type}
+		}
+		if err != nil {
+			return fmt.Errorf("error formatting field '%s' : %w", f.path, err)
+		}
+
+		kvs = append(kvs, [2]string{f.tag.Env, value})
+		return nil
+	})
+
+	return kvs, err
+}
+
+// Apply sets every variable of obj in the process environment.
+func Apply(obj interface{}, opts ...Option) error {
+	kvs, err := marshal(obj, newOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range kvs {
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
+			return fmt.Errorf("error exporting env '%s' : %w", kv[0], err)
+		}
+	}
+
+	return nil
+}
+
+// ToSlice returns the variables of obj as KEY=value strings in field order,
+// suitable for exec.Cmd.Env.
+func ToSlice(obj interface{}, opts ...Option) ([]string, error) {
+	kvs, err := marshal(obj, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	environ := make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		environ = append(environ, kv[0]+"="+kv[1])
+	}
+	return environ, nil
+}
@@ -0,0 +1,251 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type marshalDB struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT"`
+}
+
+type marshalConfig struct {
+	Name    string            `env:"NAME"`
+	Debug   bool              `env:"DEBUG"`
+	Timeout time.Duration     `env:"TIMEOUT"`
+	Hosts   []string          `env:"HOSTS,separator=;"`
+	Ports   []int             `env:"PORTS"`
+	Labels  map[string]string `env:"LABELS"`
+	Ratio   float64           `env:"RATIO"`
+	Limit   *int              `env:"LIMIT,optional"`
+	DB      marshalDB         `envPrefix:"DB_"`
+	Replica *marshalDB        `envPrefix:"REPLICA_"`
+	Skipped string            `env:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+	limit := 10
+
+	tests := []struct {
+		name string
+		cfg  marshalConfig
+		opts []Option
+		want map[string]string
+	}{
+		{
+			name: "values",
+			cfg: marshalConfig{
+				Name: "app", Debug: true, Timeout: 90 * time.Second,
+				Hosts: []string{"a,1", "b"}, Ports: []int{80, 443}, Labels: map[string]string{"app": "web"},
+				Ratio: 0.5, Limit: &limit, DB: marshalDB{Host: "db", Port: 5432}, Skipped: "x",
+			},
+			want: map[string]string{
+				"NAME": "app", "DEBUG": "true", "TIMEOUT": "1m30s",
+				"HOSTS": "a,1;b", "PORTS": "80,443", "LABELS": "app=web",
+				"RATIO": "0.5", "LIMIT": "10", "DB_HOST": "db", "DB_PORT": "5432",
+			},
+		},
+		{
+			name: "zero values and nil pointers",
+			cfg:  marshalConfig{Replica: &marshalDB{Host: "replica"}},
+			opts: []Option{WithPrefix("APP_"), WithSeparator(" ")},
+			want: map[string]string{
+				"APP_NAME": "", "APP_DEBUG": "false", "APP_TIMEOUT": "0s",
+				"APP_HOSTS": "", "APP_PORTS": "", "APP_LABELS": "", "APP_RATIO": "0",
+				"APP_DB_HOST": "", "APP_DB_PORT": "0", "APP_REPLICA_HOST": "replica", "APP_REPLICA_PORT": "0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(&tt.cfg, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, found %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	limit := 10
+	want := marshalConfig{
+		Name: "my app", Debug: true, Timeout: time.Minute,
+		Hosts: []string{"a", "b"}, Ports: []int{80}, Labels: map[string]string{"k": "v"},
+		Ratio: 1.25, Limit: &limit, DB: marshalDB{Host: "db", Port: 5432}, Replica: &marshalDB{Host: "r", Port: 1},
+	}
+
+	vars, err := Marshal(&want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := MarshalWriter(&want, &buf); err != nil {
+		t.Fatal(err)
+	}
+	written, err := ReadDotenv(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, vars) {
+		t.Errorf("expected MarshalWriter to write %v, found %v", vars, written)
+	}
+
+	var got marshalConfig
+	if err := ParseFromMap(&got, vars); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, found %+v", want, got)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  interface{}
+	}{
+		{name: "nil", obj: nil},
+		{name: "not a struct", obj: "config"},
+		{name: "unsupported field", obj: &struct {
+			C chan int `env:"C"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Marshal(tt.obj); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	unsetenv(t, "APPLY_NAME", "APPLY_PORTS", "APPLY_DB_HOST", "APPLY_DB_PORT")
+
+	type config struct {
+		Name  string    `env:"NAME"`
+		Ports []int     `env:"PORTS"`
+		DB    marshalDB `envPrefix:"DB_"`
+	}
+	cfg := config{Name: "app", Ports: []int{80, 443}, DB: marshalDB{Host: "db"}}
+
+	if err := Apply(&cfg, WithPrefix("APPLY_")); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"APPLY_NAME": "app", "APPLY_PORTS": "80,443", "APPLY_DB_HOST": "db", "APPLY_DB_PORT": "0"} {
+		if got, ok := os.LookupEnv(key); !ok || got != want {
+			t.Errorf("expected %s to be '%s', found '%s'", key, want, got)
+		}
+	}
+
+	var got config
+	if err := ParseWithOptions(&got, WithPrefix("APPLY_")); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("expected %+v, found %+v", cfg, got)
+	}
+}
+
+func TestToSlice(t *testing.T) {
+	type config struct {
+		Name string        `env:"NAME"`
+		Wait time.Duration `env:"WAIT"`
+		Path string        `env:"PATH_LIST"`
+	}
+
+	got, err := ToSlice(&config{Name: "my app", Wait: time.Second, Path: "a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// values are not quoted, exec.Cmd passes them as they are
+	want := []string{"NAME=my app", "WAIT=1s", "PATH_LIST=a=b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, found %q", want, got)
+	}
+
+	if vars := parseEnviron(got); vars["PATH_LIST"] != "a=b" {
+		t.Errorf("expected the entries to read back, found %v", vars)
+	}
+}
+
+func TestWriteShell(t *testing.T) {
+	type config struct {
+		Name  string   `env:"NAME"`
+		Hosts []string `env:"HOSTS"`
+		Quote string   `env:"QUOTE"`
+		Empty string   `env:"EMPTY"`
+	}
+
+	var buf bytes.Buffer
+	if err := WriteShell(&buf, &config{Name: "app", Hosts: []string{"a", "b"}, Quote: "it's $HOME"}, WithPrefix("APP_")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "export APP_NAME=app\n" +
+		"export APP_HOSTS=a,b\n" +
+		"export APP_QUOTE='it'\\''s $HOME'\n" +
+		"export APP_EMPTY=''\n"
+	if buf.String() != want {
+		t.Errorf("expected\n%s\nfound\n%s", want, buf.String())
+	}
+}
+
+func TestWriteSystemd(t *testing.T) {
+	type config struct {
+		Name  string `env:"NAME"`
+		Greet string `env:"GREET"`
+		Cmd   string `env:"CMD"`
+		Empty string `env:"EMPTY"`
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSystemd(&buf, &config{Name: "app", Greet: "hello world", Cmd: "echo \"$1\" \\ `x`"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "NAME=app\n" +
+		"GREET=\"hello world\"\n" +
+		"CMD=\"echo \\\"\\$1\\\" \\\\ \\`x\\`\"\n" +
+		"EMPTY=\n"
+	if buf.String() != want {
+		t.Errorf("expected\n%s\nfound\n%s", want, buf.String())
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		value   string
+		shell   string
+		systemd string
+	}{
+		{value: "", shell: "''", systemd: ""},
+		{value: "a-b_c.d/e:f@g", shell: "a-b_c.d/e:f@g", systemd: "a-b_c.d/e:f@g"},
+		{value: "a b", shell: "'a b'", systemd: `"a b"`},
+		{value: "#comment", shell: "'#comment'", systemd: `"#comment"`},
+		{value: "a;b", shell: "'a;b'", systemd: `"a;b"`},
+		{value: "line\nbreak", shell: "'line\nbreak'", systemd: "\"line\nbreak\""},
+		{value: "*", shell: "'*'", systemd: "*"},
+	}
+
+	for _, tt := range tests {
+		if got := quoteShell(tt.value); got != tt.shell {
+			t.Errorf("expected %q to be quoted for shells as %q, found %q", tt.value, tt.shell, got)
+		}
+		if got := quoteSystemd(tt.value); got != tt.systemd {
+			t.Errorf("expected %q to be quoted for systemd as %q, found %q", tt.value, tt.systemd, got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package env
+
+import (
+	"strings"
+	"unicode"
+)
+
+type NameMapper func(path []string) string
+
+// SnakeUpper maps a field path like [Database MaxConns] to DATABASE_MAX_CONNS.
+func SnakeUpper(path []string) string {
+	parts := make([]string, len(path))
+	for i, name := range path {
+		parts[i] = strings.ToUpper(snakeCase(name))
+	}
+	return strings.Join(parts, "_")
+}
+
+// UpperPath maps a field path like [Database MaxConns] to
+// DATABASE_MAXCONNS, the names envconfig derives without split_words.
+func UpperPath(path []string) string {
+	return strings.ToUpper(strings.Join(path, "_"))
+}
+
+// rename applies Options.Rename to a variable name.
+func (o Options) rename(name string) string {
+	if o.Rename == nil {
+		return name
+	}
+	return o.Rename(name)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,193 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnakeUpper(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+	}{
+		{path: []string{"Host"}, want: "HOST"},
+		{path: []string{"Database", "MaxConns"}, want: "DATABASE_MAX_CONNS"},
+		{path: []string{"HTTPServer", "TLSCert"}, want: "HTTP_SERVER_TLS_CERT"},
+		{path: []string{"OAuth2Token"}, want: "O_AUTH2_TOKEN"},
+		{path: []string{"Server", "Port8080"}, want: "SERVER_PORT8080"},
+		{path: []string{"ID"}, want: "ID"},
+		{path: []string{"userID"}, want: "USER_ID"},
+	}
+
+	for _, tt := range tests {
+		if got := SnakeUpper(tt.path); got != tt.want {
+			t.Errorf("expected %v to map to '%s', found '%s'", tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestAutoNames(t *testing.T) {
+	type database struct {
+		MaxConns int
+		Host     string `env:",default=localhost"`
+	}
+	type config struct {
+		Name     string
+		Port     int `env:"LISTEN_PORT"`
+		Database database
+		Replica  database `envPrefix:"REPLICA_"`
+		internal string
+	}
+
+	tests := []struct {
+		name   string
+		mapper NameMapper
+		vars   Map
+		want   config
+	}{
+		{
+			name:   "snake upper",
+			mapper: SnakeUpper,
+			vars:   Map{"NAME": "app", "LISTEN_PORT": "80", "DATABASE_MAX_CONNS": "10", "REPLICA_MAX_CONNS": "5", "REPLICA_HOST": "replica", "INTERNAL": "x"},
+			want:   config{Name: "app", Port: 80, Database: database{MaxConns: 10, Host: "localhost"}, Replica: database{MaxConns: 5, Host: "replica"}},
+		},
+		{
+			name:   "upper path",
+			mapper: UpperPath,
+			vars:   Map{"NAME": "app", "LISTEN_PORT": "80", "DATABASE_MAXCONNS": "10", "REPLICA_MAXCONNS": "5"},
+			want:   config{Name: "app", Port: 80, Database: database{MaxConns: 10, Host: "localhost"}, Replica: database{MaxConns: 5, Host: "localhost"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic(), WithAutoNames(tt.mapper)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+
+	t.Run("untagged fields are skipped without auto names", func(t *testing.T) {
+		var cfg struct {
+			Name string
+			Port int `env:"LISTEN_PORT"`
+		}
+		if err := ParseFromMap(&cfg, map[string]string{"NAME": "app", "LISTEN_PORT": "80"}); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Name != "" || cfg.Port != 80 {
+			t.Errorf("expected only the tagged field to be set, found %+v", cfg)
+		}
+	})
+}
+
+func TestLegacyNames(t *testing.T) {
+	type database struct {
+		MaxConns int `env:"MAX_CONNECTIONS"`
+	}
+	type config struct {
+		Host     string   `env:"SERVER_HOST|HOST"`
+		Database database `envPrefix:"DB_"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		want config
+	}{
+		{
+			name: "tag names",
+			vars: Map{"SERVER_HOST": "new", "DB_MAX_CONNECTIONS": "10", "DB_MAXCONNS": "5"},
+			want: config{Host: "new", Database: database{MaxConns: 10}},
+		},
+		{
+			name: "legacy names",
+			vars: Map{"DB_MAXCONNS": "5", "HOST": "alias"},
+			want: config{Host: "alias", Database: database{MaxConns: 5}},
+		},
+		{
+			name: "legacy after aliases",
+			vars: Map{"DB_MAXCONNS": "5", "HOST": "alias", "DB_HOST": "unrelated"},
+			want: config{Host: "alias", Database: database{MaxConns: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic(), WithLegacyNames(func(path []string) string {
+				return UpperPath(path[len(path)-1:])
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST|HOSTNAME,deprecated=SERVER"`
+		Port int    `env:"PORT,default=80"`
+		URL  string `env:"URL,default=http://${HOST}:${PORT}"`
+		Cert string `env:"CERT,requiredIf=TLS=true"`
+	}
+
+	rename := WithRename(func(name string) string { return "MYAPP_" + name })
+
+	tests := []struct {
+		name string
+		vars Map
+		want config
+		err  bool
+	}{
+		{
+			name: "names and references",
+			vars: Map{"MYAPP_HOSTNAME": "example.com", "HOST": "unprefixed", "PORT": "8080"},
+			want: config{Host: "example.com", Port: 80, URL: "http://example.com:80"},
+		},
+		{
+			name: "deprecated",
+			vars: Map{"MYAPP_SERVER": "old", "MYAPP_PORT": "8080"},
+			want: config{Host: "old", Port: 8080, URL: "http://old:8080"},
+		},
+		{
+			name: "conditions",
+			vars: Map{"MYAPP_HOST": "h", "MYAPP_TLS": "true"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, WithLookuper(tt.vars), WithHermetic(), rename)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && cfg != tt.want {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+
+	t.Run("conflicts", func(t *testing.T) {
+		var cfg struct {
+			Host string `env:"HOST"`
+			Name string `env:"host"`
+		}
+		err := ParseWithOptions(&cfg, WithLookuper(Map{"HOST": "h"}), WithHermetic(), WithRename(strings.ToUpper))
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("expected ErrConflict, found %v", err)
+		}
+	})
+}
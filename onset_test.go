@@ -0,0 +1,49 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOnSetRedaction(t *testing.T) {
+	type config struct {
+		Host     string `env:"HOST"`
+		Password string `env:"PASSWORD,secret"`
+		Key      string `env:"KEY,file"`
+		Token    string `env:"TOKEN"`
+	}
+
+	type set struct {
+		env, value, source string
+	}
+
+	fsys := fstest.MapFS{
+		"key":   {Data: []byte("private key")},
+		"token": {Data: []byte("token value")},
+	}
+	vars := Map{"HOST": "localhost", "PASSWORD": "hunter2", "KEY": "/key", "TOKEN_FILE": "/token"}
+
+	var got []set
+	var cfg config
+	err := ParseWithOptions(&cfg, WithLookuper(vars), WithHermetic(), WithFS(fsys), WithFileFallback(),
+		WithOnSet(func(f FieldInfo, value, source string) {
+			got = append(got, set{f.Env, value, source})
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []set{
+		{"HOST", "localhost", SourceLookuper},
+		{"PASSWORD", Redacted, SourceLookuper},
+		{"KEY", Redacted, SourceFile},
+		{"TOKEN", Redacted, SourceFile},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, found %q", want, got)
+	}
+	if cfg.Key != "private key" || cfg.Token != "token value" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
@@ -0,0 +1,336 @@
+package env
+
+import (
+	"io/fs"
+	"reflect"
+)
+
+type Options struct {
+	// ExportResolved writes values that did not come from the process
+	// environment (e.g. defaults) back with os.Setenv once parsing succeeds.
+	ExportResolved bool
+
+	// Flags are consulted before every other source, keyed by variable name
+	// without Prefix. See RegisterFlags and FlagSet.
+	Flags Lookuper
+
+	// Vars are consulted before the process environment.
+	Vars map[string]string
+
+	// Lookuper replaces the process environment as the source of values.
+	Lookuper Lookuper
+
+	// Prefix is prepended to every variable name, including those of nested
+	// structs namespaced with the envPrefix tag.
+	Prefix string
+
+	// AutoNames derives variable names for untagged fields, and for tags
+	// without a name, from the field path.
+	AutoNames NameMapper
+
+	// LegacyNames derives an additional alias for every field from its
+	// path, read after the names in the tag, for reading variables named
+	// by an older convention while migrating.
+	LegacyNames NameMapper
+
+	// Rename transforms every variable name after prefixes are applied,
+	// including aliases and the names conditions and defaults refer to.
+	Rename func(name string) string
+
+	// Separator splits slice values when the tag does not set one, defaults
+	// to a comma.
+	Separator string
+
+	// DefaultOptional makes fields optional unless tagged required.
+	DefaultOptional bool
+
+	// AllowEmpty treats variables that are set to the empty string as set,
+	// so they satisfy required fields and suppress defaults.
+	AllowEmpty bool
+
+	// KeepExisting treats fields that already hold a non-zero value as
+	// defaulted to it, as the keep tag option does for a single field.
+	KeepExisting bool
+
+	// AllocateStructs allocates nil struct pointers even when none of their
+	// variables are set. By default they are only allocated when at least
+	// one variable is present.
+	AllocateStructs bool
+
+	// Hermetic resolves values from Vars and Lookuper only and never touches
+	// the operating system, so parsing is fully deterministic.
+	Hermetic bool
+
+	// Snapshot resolves every field from a single copy of the operating
+	// system environment taken at the start of parsing.
+	Snapshot bool
+
+	// CaseInsensitive resolves variables regardless of the case of their
+	// names. It implies Snapshot.
+	CaseInsensitive bool
+
+	// TagName replaces env as the name of the tag fields are read from,
+	// such as envconfig for structs written for another library.
+	TagName string
+
+	// CompanionTags also reads options from the separate tags of other
+	// libraries: envDefault, default, envSeparator, envKeyValSeparator and
+	// required.
+	CompanionTags bool
+
+	// Logger receives a debug trace of every field: the names tried, the
+	// source and the resulting value, with secrets redacted.
+	Logger Logger
+
+	// SanitizeErrors hides values in parse and rule errors, keeping the
+	// variable and field names. Errors of secret and file fields are always
+	// sanitized.
+	SanitizeErrors bool
+
+	// FileFallback reads the value of an unset variable from the file named
+	// by <VAR>_FILE, the convention used for Docker and Kubernetes secrets.
+	FileFallback bool
+
+	// UnsetAfterRead removes every variable read from the process
+	// environment once parsing succeeds, as the unset tag option does for a
+	// single field.
+	UnsetAfterRead bool
+
+	// Strict reports variables starting with Prefix that no field consumes,
+	// catching misspelled names. It is an error without a prefix.
+	Strict bool
+
+	// Dotenv files are layered beneath the other sources, later files
+	// overriding earlier ones.
+	Dotenv []string
+
+	// FS is used for all file reads when set. Absolute paths are resolved
+	// relative to its root.
+	FS fs.FS
+
+	// Parsers take precedence over parsers added with RegisterParser.
+	Parsers map[reflect.Type]ParserFunc
+
+	// Validators take precedence over validators added with
+	// RegisterValidator.
+	Validators map[string]ValidatorFunc
+
+	// Decryptor returns the plaintext of values of fields with the encrypted
+	// tag option and of values starting with EncryptedPrefix, which is
+	// removed first.
+	Decryptor func(ciphertext string) (string, error)
+
+	// OnSet is called for every populated field with its raw value, redacted
+	// for secrets and values read from files, and the source it came from.
+	OnSet func(field FieldInfo, value string, source string)
+
+	// OnDeprecated is called when a field is read from one of its deprecated
+	// names, with the name that was set.
+	OnDeprecated func(field FieldInfo, deprecated string)
+
+	dotenv  map[string]string
+	environ map[string]string
+	folded  folded
+	report  *Report
+	plan    *plan
+}
+
+type Option func(*Options)
+
+func newOptions(opts []Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func WithExportResolved() Option {
+	return func(o *Options) {
+		o.ExportResolved = true
+	}
+}
+
+func WithVars(vars map[string]string) Option {
+	return func(o *Options) {
+		o.Vars = vars
+	}
+}
+
+func WithFlags(flags Lookuper) Option {
+	return func(o *Options) {
+		o.Flags = flags
+	}
+}
+
+func WithLookuper(l Lookuper) Option {
+	return func(o *Options) {
+		o.Lookuper = l
+	}
+}
+
+func WithPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.Prefix = prefix
+	}
+}
+
+func WithAutoNames(m NameMapper) Option {
+	return func(o *Options) {
+		o.AutoNames = m
+	}
+}
+
+func WithLegacyNames(m NameMapper) Option {
+	return func(o *Options) {
+		o.LegacyNames = m
+	}
+}
+
+func WithRename(fn func(name string) string) Option {
+	return func(o *Options) {
+		o.Rename = fn
+	}
+}
+
+func WithSeparator(sep string) Option {
+	return func(o *Options) {
+		o.Separator = sep
+	}
+}
+
+func WithDefaultOptional() Option {
+	return func(o *Options) {
+		o.DefaultOptional = true
+	}
+}
+
+func WithAllowEmpty() Option {
+	return func(o *Options) {
+		o.AllowEmpty = true
+	}
+}
+
+func WithKeepExisting() Option {
+	return func(o *Options) {
+		o.KeepExisting = true
+	}
+}
+
+func WithAllocateStructs() Option {
+	return func(o *Options) {
+		o.AllocateStructs = true
+	}
+}
+
+func WithSnapshot() Option {
+	return func(o *Options) {
+		o.Snapshot = true
+	}
+}
+
+func WithCaseInsensitive() Option {
+	return func(o *Options) {
+		o.CaseInsensitive = true
+	}
+}
+
+func WithHermetic() Option {
+	return func(o *Options) {
+		o.Hermetic = true
+	}
+}
+
+func WithTagName(name string) Option {
+	return func(o *Options) {
+		o.TagName = name
+	}
+}
+
+func WithCompanionTags() Option {
+	return func(o *Options) {
+		o.CompanionTags = true
+	}
+}
+
+func WithLogger(l Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+func WithSanitizedErrors() Option {
+	return func(o *Options) {
+		o.SanitizeErrors = true
+	}
+}
+
+func WithFileFallback() Option {
+	return func(o *Options) {
+		o.FileFallback = true
+	}
+}
+
+func WithUnsetAfterRead() Option {
+	return func(o *Options) {
+		o.UnsetAfterRead = true
+	}
+}
+
+func WithStrict() Option {
+	return func(o *Options) {
+		o.Strict = true
+	}
+}
+
+func WithDotenv(paths ...string) Option {
+	return func(o *Options) {
+		o.Dotenv = append(o.Dotenv, paths...)
+	}
+}
+
+func WithFS(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.FS = fsys
+	}
+}
+
+func WithParsers(parsers map[reflect.Type]ParserFunc) Option {
+	return func(o *Options) {
+		if o.Parsers == nil {
+			o.Parsers = map[reflect.Type]ParserFunc{}
+		}
+		for t, fn := range parsers {
+			o.Parsers[t] = fn
+		}
+	}
+}
+
+func WithValidators(validators map[string]ValidatorFunc) Option {
+	return func(o *Options) {
+		if o.Validators == nil {
+			o.Validators = map[string]ValidatorFunc{}
+		}
+		for name, fn := range validators {
+			o.Validators[name] = fn
+		}
+	}
+}
+
+func WithOnDeprecated(fn func(field FieldInfo, deprecated string)) Option {
+	return func(o *Options) {
+		o.OnDeprecated = fn
+	}
+}
+
+func WithDecryptor(fn func(ciphertext string) (string, error)) Option {
+	return func(o *Options) {
+		o.Decryptor = fn
+	}
+}
+
+func WithOnSet(fn func(field FieldInfo, value string, source string)) Option {
+	return func(o *Options) {
+		o.OnSet = fn
+	}
+}
@@ -0,0 +1,75 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type ParserFunc func(value string) (interface{}, error)
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = builtinParsers()
+)
+
+func RegisterParser(t reflect.Type, fn ParserFunc) {
+	parsersMu.Lock()
+	parsers[t] = fn
+	parsersMu.Unlock()
+
+	clearConflicts()
+}
+
+func (o Options) parser(t reflect.Type) (ParserFunc, bool) {
+	if fn, ok := o.Parsers[t]; ok {
+		return fn, true
+	}
+
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	fn, ok := parsers[t]
+	return fn, ok
+}
+
+type ValidatorFunc func(value string) error
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]ValidatorFunc{}
+)
+
+func RegisterValidator(name string, fn ValidatorFunc) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+
+	validators[name] = fn
+}
+
+func (o Options) validator(name string) (ValidatorFunc, bool) {
+	if fn, ok := o.Validators[name]; ok {
+		return fn, true
+	}
+
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	fn, ok := validators[name]
+	return fn, ok
+}
+
+func callParser(fn ParserFunc, v reflect.Value, value string) error {
+	parsed, err := fn(value)
+	if err != nil {
+		return err
+	}
+
+	pv := reflect.ValueOf(parsed)
+	if !pv.IsValid() || !pv.Type().AssignableTo(v.Type()) {
+		return fmt.Errorf("parser returned '%T', expected '%s'", parsed, v.Type())
+	}
+
+	v.Set(pv)
+	return nil
+}
@@ -0,0 +1,126 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type parserPoint struct {
+	X, Y string
+}
+
+func parsePoint(value string) (interface{}, error) {
+	x, y, ok := strings.Cut(value, ",")
+	if !ok {
+		return nil, errors.New("expected 'x,y'")
+	}
+	return parserPoint{X: x, Y: y}, nil
+}
+
+func TestParsers(t *testing.T) {
+	pointType := reflect.TypeOf(parserPoint{})
+	regexpType := reflect.TypeOf((*regexp.Regexp)(nil))
+
+	RegisterParser(pointType, parsePoint)
+	t.Cleanup(func() {
+		parsersMu.Lock()
+		delete(parsers, pointType)
+		parsersMu.Unlock()
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		testSetValue(t, pointType, []setValueTest{
+			{value: "1,2", want: parserPoint{X: "1", Y: "2"}},
+			{value: "1", err: true},
+		})
+	})
+
+	t.Run("slices and pointers of registered types", func(t *testing.T) {
+		testSetValue(t, reflect.TypeOf([]*parserPoint{}), []setValueTest{
+			{value: `"1,2","3,4"`, want: []*parserPoint{{X: "1", Y: "2"}, {X: "3", Y: "4"}}},
+		})
+	})
+
+	t.Run("options override the registry", func(t *testing.T) {
+		swapped := func(value string) (interface{}, error) {
+			x, y, _ := strings.Cut(value, ",")
+			return parserPoint{X: y, Y: x}, nil
+		}
+		testSetValue(t, pointType, []setValueTest{
+			{value: "1,2", want: parserPoint{X: "2", Y: "1"}},
+		}, WithParsers(map[reflect.Type]ParserFunc{pointType: swapped}))
+	})
+
+	t.Run("pointer types", func(t *testing.T) {
+		compile := func(value string) (interface{}, error) { return regexp.Compile(value) }
+		testSetValue(t, regexpType, []setValueTest{
+			{value: "^a+$", want: regexp.MustCompile("^a+$")},
+			{value: "(", err: true},
+		}, WithParsers(map[reflect.Type]ParserFunc{regexpType: compile}))
+	})
+
+	t.Run("wrong result type", func(t *testing.T) {
+		wrong := func(string) (interface{}, error) { return 42, nil }
+		_, err := setValue(pointType, "1,2", WithParsers(map[reflect.Type]ParserFunc{pointType: wrong}))
+		if want := "parser returned 'int', expected 'env.parserPoint'"; err == nil || err.Error() != want {
+			t.Errorf("expected %q, found %v", want, err)
+		}
+	})
+}
+
+func TestValidators(t *testing.T) {
+	errNotURL := errors.New("not a url")
+	RegisterValidator("test-url", func(value string) error {
+		if !strings.Contains(value, "://") {
+			return errNotURL
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		validatorsMu.Lock()
+		delete(validators, "test-url")
+		validatorsMu.Unlock()
+	})
+
+	errNotHTTPS := errors.New("not https")
+	https := WithValidators(map[string]ValidatorFunc{
+		"https": func(value string) error {
+			if !strings.HasPrefix(value, "https://") {
+				return errNotHTTPS
+			}
+			return nil
+		},
+	})
+
+	tests := []struct {
+		name  string
+		tag   reflect.StructTag
+		opts  []Option
+		value string
+		err   error
+	}{
+		{name: "registered", tag: `env:"V,validate=test-url"`, value: "http://example.com"},
+		{name: "registered fails", tag: `env:"V,validate=test-url"`, value: "example.com", err: errNotURL},
+		{name: "option", tag: `env:"V,validate=https"`, opts: []Option{https}, value: "https://example.com"},
+		{name: "chained", tag: `env:"V,validate=test-url|https"`, opts: []Option{https}, value: "http://example.com", err: errNotHTTPS},
+		{name: "unknown", tag: `env:"V,validate=missing"`, value: "x", err: ErrValidation},
+		{name: "option is per call", tag: `env:"V,validate=https"`, value: "https://example.com", err: ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: reflect.TypeOf(""), Tag: tt.tag}})
+
+			err := ParseWithOptions(reflect.New(typ).Interface(), append([]Option{WithLookuper(Map{"V": tt.value}), WithHermetic()}, tt.opts...)...)
+			if tt.err == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != nil && (!errors.Is(err, tt.err) || !errors.Is(err, ErrValidation)) {
+				t.Errorf("expected %v, found %v", tt.err, err)
+			}
+		})
+	}
+}
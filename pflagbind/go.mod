@@ -0,0 +1,10 @@
+module github.com/reverted/env/pflagbind
+
+go 1.20
+
+require (
+	github.com/reverted/env v0.0.0
+	github.com/spf13/pflag v1.0.5
+)
+
+replace github.com/reverted/env => ../
@@ -0,0 +1,89 @@
+// Package pflagbind binds the variables of a config struct to a pflag
+// FlagSet, so the same struct can be configured with flags, including from
+// cobra commands through cmd.Flags().
+//
+//	var cfg Config
+//	pflagbind.Bind(cmd.Flags(), &cfg)
+//
+//	// after the flags are parsed
+//	err := env.ParseWithOptions(&cfg, env.WithFlags(pflagbind.FlagSet(cmd.Flags())))
+//
+// pflagbind is a module of its own so that env does not depend on pflag. It
+// builds against the env in the parent directory until a release of env holds
+// the API it uses.
+package pflagbind
+
+import (
+	"strings"
+
+	"github.com/reverted/env"
+	"github.com/spf13/pflag"
+)
+
+// Bind defines a flag for every variable obj consumes, named with
+// env.FlagName after stripping the prefix and described by its desc tag.
+// Variables of indexed and keyed fields get no flag.
+func Bind(fs *pflag.FlagSet, obj interface{}, opts ...env.Option) error {
+	var o env.Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	specs, err := env.Describe(obj, opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range specs {
+		if spec.Pattern {
+			continue
+		}
+
+		f := fs.VarPF(&value{value: spec.Default, typ: spec.Type}, env.FlagName(strings.TrimPrefix(spec.Name, o.Prefix)), "", spec.Description)
+		if spec.Type == "bool" || spec.Type == "*bool" {
+			f.NoOptDefVal = "true"
+		}
+	}
+
+	return nil
+}
+
+type value struct {
+	value string
+	typ   string
+}
+
+func (v *value) String() string {
+	return v.value
+}
+
+func (v *value) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *value) Type() string {
+	return v.typ
+}
+
+type flagSet struct {
+	fs *pflag.FlagSet
+}
+
+// FlagSet looks up variables in the flags of fs that were changed on the
+// command line.
+func FlagSet(fs *pflag.FlagSet) env.Lookuper {
+	return flagSet{fs: fs}
+}
+
+func (f flagSet) Lookup(key string) (string, bool) {
+	fl := f.fs.Lookup(env.FlagName(key))
+	if fl == nil || !fl.Changed {
+		return "", false
+	}
+	return fl.Value.String(), true
+}
+
+func (f flagSet) SourceName() string {
+	return env.SourceFlag
+}
@@ -0,0 +1,91 @@
+package pflagbind
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/reverted/env"
+	"github.com/spf13/pflag"
+)
+
+type server struct {
+	Host string `env:"HOST"`
+}
+
+type config struct {
+	Host    string   `env:"DB_HOST,default=localhost" desc:"database host"`
+	Port    int      `env:"DB_PORT,default=5432"`
+	Debug   bool     `env:"DEBUG,optional"`
+	Servers []server `envPrefix:"SERVERS_"`
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		vars env.Map
+		want config
+	}{
+		{name: "defaults", want: config{Host: "localhost", Port: 5432}},
+		{name: "environment", vars: env.Map{"APP_DB_HOST": "env"}, want: config{Host: "env", Port: 5432}},
+		{name: "flags override the environment", args: []string{"--db-host=flag", "--debug"}, vars: env.Map{"APP_DB_HOST": "env"}, want: config{Host: "flag", Port: 5432, Debug: true}},
+		{name: "explicit bool", args: []string{"--debug=false", "--db-port", "1"}, vars: env.Map{"APP_DEBUG": "true"}, want: config{Host: "localhost", Port: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			if err := Bind(fs, &config{}, env.WithPrefix("APP_")); err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			var cfg config
+			err := env.ParseWithOptions(&cfg, env.WithPrefix("APP_"), env.WithFlags(FlagSet(fs)), env.WithLookuper(tt.vars), env.WithHermetic())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestBindFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if err := Bind(fs, &config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, typ, def, usage, noOpt string
+	}{
+		{name: "db-host", typ: "string", def: "localhost", usage: "database host"},
+		{name: "db-port", typ: "int", def: "5432"},
+		{name: "debug", typ: "bool", noOpt: "true"},
+	}
+
+	var names []string
+	fs.VisitAll(func(f *pflag.Flag) {
+		names = append(names, f.Name)
+	})
+	if want := []string{"db-host", "db-port", "debug"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected flags %q, found %q", want, names)
+	}
+
+	for _, tt := range tests {
+		f := fs.Lookup(tt.name)
+		if f == nil {
+			t.Errorf("missing flag '%s'", tt.name)
+			continue
+		}
+		if f.Value.Type() != tt.typ || f.DefValue != tt.def || f.Usage != tt.usage || f.NoOptDefVal != tt.noOpt {
+			t.Errorf("unexpected flag '%s' : %+v", tt.name, f)
+		}
+	}
+}
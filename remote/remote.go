@@ -0,0 +1,236 @@
+// Package remote resolves variables from a JSON or dotenv document fetched
+// over HTTP, such as one served by a configuration or feature flag service.
+//
+// The document is fetched on first use and revalidated with its ETag once the
+// TTL expires, so an unchanged document is not transferred again.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reverted/env"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatDotenv = "dotenv"
+)
+
+type options struct {
+	format  string
+	client  *http.Client
+	ttl     time.Duration
+	request func(req *http.Request) error
+}
+
+type Option func(*options)
+
+// WithFormat sets the document format, FormatJSON or FormatDotenv. By default
+// it is taken from the Content-Type of the response, or from the first
+// character of the document when that does not say.
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithTTL sets how long the document is used before it is revalidated,
+// defaults to thirty seconds.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithRequest is called with every request before it is sent, typically to
+// add an authorization header.
+func WithRequest(fn func(req *http.Request) error) Option {
+	return func(o *options) {
+		o.request = fn
+	}
+}
+
+// WithBearerToken authorizes requests with the given token.
+func WithBearerToken(token string) Option {
+	return WithRequest(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+type Source struct {
+	url  string
+	opts options
+
+	mu      sync.Mutex
+	data    map[string]string
+	etag    string
+	dataExp time.Time
+}
+
+func New(url string, opts ...Option) *Source {
+	o := options{
+		client: http.DefaultClient,
+		ttl:    30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Source{url: url, opts: o}
+}
+
+func (s *Source) Lookup(key string) (string, bool) {
+	value, ok, _ := s.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (s *Source) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	data, err := s.document(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, ok := data[key]
+	return value, ok, nil
+}
+
+// Keys lists the variables in the document, errors are treated as an empty
+// document.
+func (s *Source) Keys() []string {
+	data, _ := s.document(context.Background())
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *Source) SourceName() string {
+	return "remote"
+}
+
+func (s *Source) document(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.data != nil && now.Before(s.dataExp) {
+		return s.data, nil
+	}
+
+	if err := s.fetch(ctx); err != nil {
+		return nil, fmt.Errorf("error fetching '%s' : %w", s.url, err)
+	}
+
+	s.dataExp = now.Add(s.opts.ttl)
+	return s.data, nil
+}
+
+func (s *Source) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+
+	if s.etag != "" && s.data != nil {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	if s.opts.request != nil {
+		if err := s.opts.request(req); err != nil {
+			return err
+		}
+	}
+
+	resp, err := s.opts.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("unexpected status %d : %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	data, err := decode(body, s.format(resp))
+	if err != nil {
+		return err
+	}
+
+	s.data, s.etag = data, resp.Header.Get("ETag")
+	return nil
+}
+
+func (s *Source) format(resp *http.Response) string {
+	if s.opts.format != "" {
+		return s.opts.format
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return FormatJSON
+		}
+	}
+
+	return ""
+}
+
+// decode reads a flat JSON object, encoding values other than strings as
+// JSON, or a dotenv document.
+func decode(body []byte, format string) (map[string]string, error) {
+	if format == "" {
+		format = FormatDotenv
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+			format = FormatJSON
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, err
+		}
+
+		data := make(map[string]string, len(doc))
+		for key, raw := range doc {
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				value = string(raw)
+			}
+			data[key] = value
+		}
+		return data, nil
+
+	case FormatDotenv:
+		return env.ReadDotenv(bytes.NewReader(body))
+	}
+
+	return nil, fmt.Errorf("unknown format '%s'", format)
+}
@@ -0,0 +1,154 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		format string
+		want   map[string]string
+		err    bool
+	}{
+		{name: "json", body: `{"HOST": "h", "PORT": 80, "TLS": true, "LIMITS": {"read": 1}}`, format: FormatJSON, want: map[string]string{"HOST": "h", "PORT": "80", "TLS": "true", "LIMITS": `{"read": 1}`}},
+		{name: "dotenv", body: "HOST=h\n# comment\nPORT=80\n", format: FormatDotenv, want: map[string]string{"HOST": "h", "PORT": "80"}},
+		{name: "sniffed json", body: ` {"HOST": "h"}`, want: map[string]string{"HOST": "h"}},
+		{name: "sniffed dotenv", body: "HOST=h\n", want: map[string]string{"HOST": "h"}},
+		{name: "invalid json", body: `["HOST"]`, format: FormatJSON, err: true},
+		{name: "invalid dotenv", body: "HOST\n", err: true},
+		{name: "unknown format", body: "HOST=h", format: "yaml", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decode([]byte(tt.body), tt.format)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, found %v", tt.want, got)
+			}
+		})
+	}
+}
+
+type server struct {
+	*httptest.Server
+	fetches, transfers int32
+	body               atomic.Value
+}
+
+func newServer(t *testing.T, contentType string) *server {
+	s := &server{}
+	s.body.Store(`{"HOST": "h"}`)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.fetches, 1)
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		body := s.body.Load().(string)
+		etag := `"` + body + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		atomic.AddInt32(&s.transfers, 1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []Option
+		want        map[string]string
+		err         bool
+	}{
+		{name: "json content type", contentType: "application/json; charset=utf-8", body: `{"HOST": "h"}`, want: map[string]string{"HOST": "h"}},
+		{name: "json suffix", contentType: "application/vnd.config+json", body: `{"HOST": "h"}`, want: map[string]string{"HOST": "h"}},
+		{name: "dotenv", contentType: "text/plain", body: "HOST=h\n", want: map[string]string{"HOST": "h"}},
+		{name: "format option", contentType: "application/json", body: "HOST=h\n", opts: []Option{WithFormat(FormatDotenv)}, want: map[string]string{"HOST": "h"}},
+		{name: "unauthorized", contentType: "application/json", body: `{}`, opts: []Option{WithBearerToken("wrong")}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, tt.contentType)
+			srv.body.Store(tt.body)
+
+			s := New(srv.URL, append([]Option{WithBearerToken("token")}, tt.opts...)...)
+
+			value, ok, err := s.LookupContext(context.Background(), "HOST")
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && (value != tt.want["HOST"] || !ok) {
+				t.Errorf("expected '%s', found '%s', %v", tt.want["HOST"], value, ok)
+			}
+		})
+	}
+}
+
+func TestSourceETag(t *testing.T) {
+	srv := newServer(t, "application/json")
+	s := New(srv.URL, WithBearerToken("token"), WithTTL(0))
+
+	lookup := func(want string) {
+		t.Helper()
+		value, _, err := s.LookupContext(context.Background(), "HOST")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != want {
+			t.Errorf("expected '%s', found '%s'", want, value)
+		}
+	}
+
+	lookup("h")
+	lookup("h")
+	if fetches, transfers := atomic.LoadInt32(&srv.fetches), atomic.LoadInt32(&srv.transfers); fetches != 2 || transfers != 1 {
+		t.Errorf("expected the document to be revalidated, found %d fetches and %d transfers", fetches, transfers)
+	}
+
+	srv.body.Store(`{"HOST": "changed"}`)
+	lookup("changed")
+	if transfers := atomic.LoadInt32(&srv.transfers); transfers != 2 {
+		t.Errorf("expected the changed document to be transferred, found %d transfers", transfers)
+	}
+}
+
+func TestSourceTTL(t *testing.T) {
+	srv := newServer(t, "application/json")
+	s := New(srv.URL, WithBearerToken("token"), WithTTL(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := s.LookupContext(context.Background(), "HOST"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if keys := s.Keys(); !reflect.DeepEqual(keys, []string{"HOST"}) {
+		t.Errorf("expected keys [HOST], found %v", keys)
+	}
+
+	if fetches := atomic.LoadInt32(&srv.fetches); fetches != 1 {
+		t.Errorf("expected 1 fetch within the TTL, found %d", fetches)
+	}
+}
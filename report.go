@@ -0,0 +1,54 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type FieldReport struct {
+	Field  string
+	Env    string
+	Set    bool
+	Source string
+	Value  string
+	Secret bool
+}
+
+type Report []FieldReport
+
+func (r Report) String() string {
+	var b strings.Builder
+	for _, f := range r {
+		source := f.Source
+		if source == "" {
+			source = "unset"
+		}
+		fmt.Fprintf(&b, "%s=%s (%s, field %s)\n", f.Env, f.Value, source, f.Field)
+	}
+	return b.String()
+}
+
+// ParseWithReport parses obj like ParseWithOptions and describes how every
+// field was resolved. The report is returned even when parsing fails.
+func ParseWithReport(obj interface{}, opts ...Option) (Report, error) {
+	var report Report
+
+	o := newOptions(opts)
+	o.report = &report
+
+	err := parse(context.Background(), obj, o)
+	return report, err
+}
+
+func (d *decoder) record(f FieldReport) {
+	if d.opts.report == nil {
+		return
+	}
+
+	if f.Secret {
+		f.Value = redact(f.Value)
+	}
+
+	d.emit(func() { *d.opts.report = append(*d.opts.report, f) })
+}
@@ -0,0 +1,42 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseWithReport(t *testing.T) {
+	type config struct {
+		Host     string `env:"HOST"`
+		Port     int    `env:"PORT,default=80"`
+		Password string `env:"PASSWORD,secret"`
+		Key      string `env:"KEY,file"`
+		Token    string `env:"TOKEN"`
+		Debug    bool   `env:"DEBUG,optional"`
+	}
+
+	fsys := fstest.MapFS{
+		"key":   {Data: []byte("private key")},
+		"token": {Data: []byte("token value")},
+	}
+	vars := Map{"HOST": "localhost", "PASSWORD": "hunter2", "KEY": "/key", "TOKEN_FILE": "/token"}
+
+	var cfg config
+	report, err := ParseWithReport(&cfg, WithLookuper(vars), WithHermetic(), WithFS(fsys), WithFileFallback())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Report{
+		{Field: "Host", Env: "HOST", Set: true, Source: SourceLookuper, Value: "localhost"},
+		{Field: "Port", Env: "PORT", Source: SourceDefault, Value: "80"},
+		{Field: "Password", Env: "PASSWORD", Set: true, Source: SourceLookuper, Value: Redacted, Secret: true},
+		{Field: "Key", Env: "KEY", Set: true, Source: SourceFile, Value: Redacted, Secret: true},
+		{Field: "Token", Env: "TOKEN", Set: true, Source: SourceFile, Value: Redacted, Secret: true},
+		{Field: "Debug", Env: "DEBUG"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("expected\n%s\nfound\n%s", want, report)
+	}
+}
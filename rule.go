@@ -0,0 +1,162 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Rule struct {
+	Name string
+	Arg  string
+
+	re *regexp.Regexp
+}
+
+func (r Rule) String() string {
+	return r.Name + "=" + r.Arg
+}
+
+func newRule(name, arg string, hasArg bool) (Rule, error) {
+	if !hasArg || arg == "" {
+		return Rule{}, fmt.Errorf("expected '%s=value'", name)
+	}
+
+	rule := Rule{Name: name, Arg: arg}
+	if name == "match" {
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.re = re
+	}
+
+	return rule, nil
+}
+
+var errRuleFailed = errors.New("value not allowed")
+
+// check returns the reason the value does not satisfy the rule, or nil.
+func (r Rule) check(v reflect.Value, raw string, opts Options) error {
+	if r.Name == "validate" {
+		for _, name := range strings.Split(r.Arg, "|") {
+			fn, ok := opts.validator(name)
+			if !ok {
+				return fmt.Errorf("unknown validator '%s'", name)
+			}
+			if err := fn(raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ok, err := r.evaluate(v, raw)
+	if err != nil {
+		return err
+	}
+	if !ok && r.Name == "oneof" {
+		return fmt.Errorf("%w, expected one of '%s'", errRuleFailed, strings.Join(strings.Split(r.Arg, "|"), "', '"))
+	}
+	if !ok {
+		return errRuleFailed
+	}
+	return nil
+}
+
+func (r Rule) evaluate(v reflect.Value, raw string) (bool, error) {
+	switch r.Name {
+	case "oneof":
+		for _, allowed := range strings.Split(r.Arg, "|") {
+			if raw == allowed {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "match":
+		return r.re.MatchString(raw), nil
+
+	case "min", "max":
+		cmp, err := compareBound(v, r.Arg)
+		if err != nil {
+			return false, fmt.Errorf("invalid rule '%s' : %w", r, err)
+		}
+		if r.Name == "min" {
+			return cmp >= 0, nil
+		}
+		return cmp <= 0, nil
+	}
+
+	return false, fmt.Errorf("unknown rule '%s'", r.Name)
+}
+
+// compareBound compares a numeric value, or the length of strings, slices and
+// maps, against a bound.
+func compareBound(v reflect.Value, arg string) (int, error) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, nil
+		}
+		v = v.Elem()
+	}
+
+	if cmp, ok, err := compareBig(v, arg); ok {
+		return cmp, err
+	}
+
+	switch {
+	case v.Type() == durationType:
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Int(), int64(bound)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bound, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Int(), bound), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bound, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Uint(), bound), nil
+
+	case reflect.Float32, reflect.Float64:
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Float(), bound), nil
+
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		bound, err := strconv.Atoi(arg)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Len(), bound), nil
+	}
+
+	return 0, fmt.Errorf("not supported for type '%s'", v.Type())
+}
+
+func compare[T int | int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		typ   reflect.Type
+		tag   reflect.StructTag
+		value string
+		rule  string
+		err   bool
+	}{
+		{name: "min", typ: reflect.TypeOf(0), tag: `env:"V,min=1,max=65535"`, value: "1"},
+		{name: "below min", typ: reflect.TypeOf(0), tag: `env:"V,min=1,max=65535"`, value: "0", rule: "min=1"},
+		{name: "max", typ: reflect.TypeOf(0), tag: `env:"V,min=1,max=65535"`, value: "65535"},
+		{name: "above max", typ: reflect.TypeOf(0), tag: `env:"V,min=1,max=65535"`, value: "65536", rule: "max=65535"},
+		{name: "float bound", typ: reflect.TypeOf(0.0), tag: `env:"V,max=0.5"`, value: "0.75", rule: "max=0.5"},
+		{name: "unsigned bound", typ: reflect.TypeOf(uint(0)), tag: `env:"V,min=2"`, value: "1", rule: "min=2"},
+		{name: "duration bound", typ: reflect.TypeOf(time.Duration(0)), tag: `env:"V,min=1s"`, value: "500ms", rule: "min=1s"},
+		{name: "string length", typ: reflect.TypeOf(""), tag: `env:"V,min=3"`, value: "ab", rule: "min=3"},
+		{name: "slice length", typ: reflect.TypeOf([]string(nil)), tag: `env:"V,max=2"`, value: "a,b,c", rule: "max=2"},
+		{name: "oneof", typ: reflect.TypeOf(""), tag: `env:"V,oneof=dev|staging|prod"`, value: "staging"},
+		{name: "not oneof", typ: reflect.TypeOf(""), tag: `env:"V,oneof=dev|staging|prod"`, value: "test", rule: "oneof=dev|staging|prod"},
+		{name: "match", typ: reflect.TypeOf(""), tag: `env:"V,match=^[a-z]{2}-"`, value: "eu-west-1"},
+		{name: "no match", typ: reflect.TypeOf(""), tag: `env:"V,match=^[a-z]{2}-"`, value: "europe", rule: "match=^[a-z]{2}-"},
+		{name: "default checked", typ: reflect.TypeOf(0), tag: `env:"V,default=0,min=1"`, rule: "min=1"},
+		{name: "invalid regexp", typ: reflect.TypeOf(""), tag: `env:"V,match=("`, value: "a", err: true},
+		{name: "invalid bound", typ: reflect.TypeOf(0), tag: `env:"V,min=one"`, value: "1", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{Name: "V", Type: tt.typ, Tag: tt.tag}})
+
+			vars := Map{}
+			if tt.value != "" {
+				vars["V"] = tt.value
+			}
+
+			err := ParseFromMap(reflect.New(typ).Interface(), vars)
+
+			var ruleErr RuleError
+			switch {
+			case tt.rule != "":
+				if !errors.As(err, &ruleErr) {
+					t.Fatalf("expected a RuleError, found %v", err)
+				}
+				if ruleErr.Rule != tt.rule || ruleErr.Env != "V" || (tt.value != "" && ruleErr.Value != tt.value) {
+					t.Errorf("expected rule '%s' for '%s', found %+v", tt.rule, tt.value, ruleErr)
+				}
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("expected the error to match ErrValidation")
+				}
+
+			case (err != nil) != tt.err:
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package env
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sanitizedError hides a value in the message of err, quoted as errors from
+// strconv and this package quote it, leaving the error chain intact.
+type sanitizedError struct {
+	err    error
+	values []string
+}
+
+func (e sanitizedError) Error() string {
+	msg := e.err.Error()
+	for _, value := range e.values {
+		msg = strings.NewReplacer(
+			strconv.Quote(value), strconv.Quote(Redacted),
+			`"`+value+`"`, `"`+Redacted+`"`,
+			"'"+value+"'", "'"+Redacted+"'",
+		).Replace(msg)
+	}
+	return msg
+}
+
+func (e sanitizedError) Unwrap() error {
+	return e.err
+}
+
+// sanitize hides value in err if the field is a secret or sanitized errors
+// are enabled. The elements and map values of a list are hidden as well,
+// since element errors quote those alone.
+func (o Options) sanitize(err error, value string, tag Tag) error {
+	if !o.sanitized(tag) {
+		return err
+	}
+
+	values := []string{value}
+	if elems, splitErr := SplitList(value, o.separator(tag), tag.NoTrim); splitErr == nil {
+		kvSep := tag.KeyValueSeparator
+		if kvSep == "" {
+			kvSep = "="
+		}
+
+		for _, elem := range elems {
+			values = append(values, elem)
+			if key, value, ok := strings.Cut(elem, kvSep); ok {
+				values = append(values, key, value)
+			}
+		}
+	}
+
+	// longer values first, so parts of a value are not replaced before it
+	sort.SliceStable(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	return sanitizedError{err: err, values: values}
+}
+
+// SanitizeError hides value in err as Parse does for secret fields, along
+// with its elements when split with SplitList. Code generated by envgen uses
+// it for the errors of secret fields.
+func SanitizeError(err error, value, sep string, noTrim bool) error {
+	return Options{SanitizeErrors: true}.sanitize(err, value, Tag{Separator: sep, NoTrim: noTrim})
+}
+
+func (o Options) sanitized(tag Tag) bool {
+	return o.SanitizeErrors || tag.Secret || tag.File
+}
@@ -0,0 +1,166 @@
+package env
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSanitizeError(t *testing.T) {
+	_, intErr := strconv.Atoi("hunter2")
+
+	tests := []struct {
+		name   string
+		err    error
+		value  string
+		sep    string
+		noTrim bool
+		want   string
+	}{
+		{
+			name:  "strconv",
+			err:   intErr,
+			value: "hunter2",
+			want:  `strconv.Atoi: parsing "******": invalid syntax`,
+		},
+		{
+			name:  "single quotes",
+			err:   errors.New("invalid value 'hunter2'"),
+			value: "hunter2",
+			want:  "invalid value '******'",
+		},
+		{
+			name:  "list element",
+			err:   errors.New(`error parsing slice element 1 : parsing "b2": invalid syntax`),
+			value: "a1;b2",
+			sep:   ";",
+			want:  `error parsing slice element 1 : parsing "******": invalid syntax`,
+		},
+		{
+			name:  "map value",
+			err:   errors.New(`invalid value "s3cret"`),
+			value: "user=s3cret",
+			want:  `invalid value "******"`,
+		},
+		{
+			name:   "untrimmed element",
+			err:    errors.New(`parsing " x": invalid syntax`),
+			value:  "1, x",
+			noTrim: true,
+			want:   `parsing "******": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SanitizeError(tt.err, tt.value, tt.sep, tt.noTrim)
+			if got := err.Error(); got != tt.want {
+				t.Errorf("expected %q, found %q", tt.want, got)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected the error chain to be kept")
+			}
+		})
+	}
+}
+
+func TestSanitizedParseErrors(t *testing.T) {
+	type config struct {
+		Pin  int `env:"PIN,secret,optional"`
+		Port int `env:"PORT,optional"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		opts []Option
+		want string
+	}{
+		{
+			name: "secret",
+			vars: Map{"PIN": "12ab"},
+			want: `error parsing env 'PIN' for field 'Pin' : strconv.ParseInt: parsing "******": invalid syntax`,
+		},
+		{
+			name: "not secret",
+			vars: Map{"PORT": "http"},
+			want: `error parsing env 'PORT' for field 'Port' : strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+		{
+			name: "with sanitized errors",
+			vars: Map{"PORT": "http"},
+			opts: []Option{WithSanitizedErrors()},
+			want: `error parsing env 'PORT' for field 'Port' : strconv.ParseInt: parsing "******": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("expected %q, found %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSanitizedRuleErrors(t *testing.T) {
+	type config struct {
+		Token string `env:"TOKEN,secret,optional,match=^tk_"`
+		Name  string `env:"NAME,optional,match=^tk_"`
+	}
+
+	tests := []struct {
+		name string
+		vars Map
+		opts []Option
+		want string
+	}{
+		{
+			name: "secret",
+			vars: Map{"TOKEN": "hunter2"},
+			want: "env 'TOKEN' for field 'Token' failed rule 'match=^tk_' with value '******' : value not allowed",
+		},
+		{
+			name: "not secret",
+			vars: Map{"NAME": "hunter2"},
+			want: "env 'NAME' for field 'Name' failed rule 'match=^tk_' with value 'hunter2' : value not allowed",
+		},
+		{
+			name: "with sanitized errors",
+			vars: Map{"NAME": "hunter2"},
+			opts: []Option{WithSanitizedErrors()},
+			want: "env 'NAME' for field 'Name' failed rule 'match=^tk_' with value '******' : value not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("expected %q, found %v", tt.want, err)
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("expected the error to match ErrValidation")
+			}
+		})
+	}
+}
+
+func TestSanitizedFileErrors(t *testing.T) {
+	type config struct {
+		Pin int `env:"PIN,file"`
+	}
+
+	fsys := fstest.MapFS{"pin": {Data: []byte("12ab\n")}}
+
+	var cfg config
+	err := ParseWithOptions(&cfg, WithLookuper(Map{"PIN": "pin"}), WithHermetic(), WithFS(fsys))
+	if err == nil || strings.Contains(err.Error(), "12ab") {
+		t.Errorf("expected the file contents to be hidden, found %v", err)
+	}
+}
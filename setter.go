@@ -0,0 +1,34 @@
+package env
+
+import (
+	"reflect"
+)
+
+// Setter is implemented by types that convert env values themselves. It is
+// used ahead of encoding.TextUnmarshaler and the built-in conversions.
+type Setter interface {
+	SetEnv(value string) error
+}
+
+var setterType = reflect.TypeOf((*Setter)(nil)).Elem()
+
+func implementsSetter(t reflect.Type) bool {
+	return t.Implements(setterType) || reflect.PtrTo(t).Implements(setterType)
+}
+
+func setEnv(v reflect.Value, value string) (bool, error) {
+	if v.Kind() == reflect.Ptr && v.Type().Implements(setterType) {
+		ptr := reflect.New(v.Type().Elem())
+		if err := ptr.Interface().(Setter).SetEnv(value); err != nil {
+			return true, err
+		}
+		v.Set(ptr)
+		return true, nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(setterType) {
+		return true, v.Addr().Interface().(Setter).SetEnv(value)
+	}
+
+	return false, nil
+}
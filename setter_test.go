@@ -0,0 +1,63 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setterLevel implements both Setter and TextUnmarshaler, Setter must win.
+type setterLevel string
+
+func (l *setterLevel) SetEnv(value string) error {
+	if value == "loud" {
+		return errors.New("unknown level")
+	}
+	*l = setterLevel("env:" + strings.ToLower(value))
+	return nil
+}
+
+func (l *setterLevel) UnmarshalText(text []byte) error {
+	*l = setterLevel("text:" + string(text))
+	return nil
+}
+
+// setterList splits on spaces, which the default slice handling would not.
+type setterList []string
+
+func (l *setterList) SetEnv(value string) error {
+	*l = strings.Fields(value)
+	return nil
+}
+
+func TestSetter(t *testing.T) {
+	tests := []struct {
+		typ   reflect.Type
+		tests []setValueTest
+	}{
+		{reflect.TypeOf(setterLevel("")), []setValueTest{{value: "DEBUG", want: setterLevel("env:debug")}, {value: "loud", err: true}}},
+		{reflect.TypeOf((*setterLevel)(nil)), []setValueTest{{value: "INFO", want: func() *setterLevel { l := setterLevel("env:info"); return &l }()}}},
+		{reflect.TypeOf(setterList(nil)), []setValueTest{{value: "a b,c", want: setterList{"a", "b,c"}}}},
+		{reflect.TypeOf([]setterLevel(nil)), []setValueTest{{value: "A,B", want: []setterLevel{"env:a", "env:b"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ.String(), func(t *testing.T) {
+			testSetValue(t, tt.typ, tt.tests)
+		})
+	}
+}
+
+func TestSetterErrors(t *testing.T) {
+	var cfg struct {
+		Level setterLevel `env:"LEVEL"`
+	}
+
+	err := ParseFromMap(&cfg, map[string]string{"LEVEL": "loud"})
+
+	var parseErr ParseError
+	if !errors.As(err, &parseErr) || parseErr.Env != "LEVEL" || parseErr.Err == nil || parseErr.Err.Error() != "unknown level" {
+		t.Errorf("expected a ParseError wrapping the Setter's error, found %v", err)
+	}
+}
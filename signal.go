@@ -0,0 +1,37 @@
+//go:build !plan9
+
+package env
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReloadOnSignal reloads h each time one of sigs is received, SIGHUP if none
+// are given, until ctx is done. After a reload that changed any field or
+// failed, onChange is called with the changes or the error, in which case the
+// current configuration is kept.
+func (h *Holder[T]) ReloadOnSignal(ctx context.Context, onChange func(Diff, error), sigs ...os.Signal) error {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ch:
+			diff, err := h.reload(ctx)
+			if err != nil || len(diff) > 0 {
+				onChange(diff, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+//go:build unix
+
+package env
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadOnSignal(t *testing.T) {
+	// keep SIGUSR1 from terminating the test before ReloadOnSignal has
+	// installed its handler
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGUSR1)
+	defer signal.Stop(ignored)
+
+	vars := &watchVars{vars: map[string]string{"HOST": "localhost", "PORT": "80"}}
+	h, err := NewHolder[watchConfig](WithLookuper(vars), WithHermetic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type event struct {
+		diff Diff
+		err  error
+	}
+	events := make(chan event)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- h.ReloadOnSignal(ctx, func(diff Diff, err error) {
+			events <- event{diff, err}
+		}, syscall.SIGUSR1)
+	}()
+
+	// until the first signal is handled, signals are resent as the handler
+	// may not be installed yet
+	installed := false
+	next := func() event {
+		t.Helper()
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+				t.Fatal(err)
+			}
+			if installed {
+				return <-events
+			}
+			select {
+			case e := <-events:
+				installed = true
+				return e
+			case <-ticker.C:
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		port string
+		diff Diff
+		err  bool
+		want int
+	}{
+		{name: "changed", port: "8080", diff: Diff{{Field: "Port", Env: "PORT", Old: 80, New: 8080}}, want: 8080},
+		{name: "changed again", port: "443", diff: Diff{{Field: "Port", Env: "PORT", Old: 8080, New: 443}}, want: 443},
+		// last, as a resent signal would report the error twice
+		{name: "invalid keeps current", port: "invalid", err: true, want: 443},
+	}
+
+	for _, tt := range tests {
+		vars.set("PORT", tt.port)
+		e := next()
+
+		if (e.err != nil) != tt.err {
+			t.Fatalf("%s: expected error %v, found %v", tt.name, tt.err, e.err)
+		}
+		if !reflect.DeepEqual(e.diff, tt.diff) {
+			t.Errorf("%s: expected %v, found %v", tt.name, tt.diff, e.diff)
+		}
+		if cfg := h.Load(); cfg.Port != tt.want {
+			t.Errorf("%s: expected port %d, found %+v", tt.name, tt.want, *cfg)
+		}
+	}
+
+	cancel()
+	for {
+		select {
+		case <-events:
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, found %v", err)
+			}
+			return
+		}
+	}
+}
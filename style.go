@@ -0,0 +1,57 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// tagStyle is the tag convention fields are read with, the zero value being
+// the env tag without companion tags.
+type tagStyle struct {
+	name       string
+	companions bool
+}
+
+func (s tagStyle) tagName() string {
+	if s.name == "" {
+		return TagName
+	}
+	return s.name
+}
+
+func (o Options) tagStyle() tagStyle {
+	name := o.TagName
+	if name == TagName {
+		name = ""
+	}
+	return tagStyle{name: name, companions: o.CompanionTags}
+}
+
+// companions applies the separate tags other libraries use for options, such
+// as envDefault of caarlos0/env and default and required of
+// kelseyhightower/envconfig. Options in the tag itself take precedence.
+func (t *Tag) companions(tag reflect.StructTag) error {
+	for _, key := range []string{"envDefault", "default"} {
+		if value, ok := tag.Lookup(key); ok && t.Default == "" {
+			t.Default = value
+		}
+	}
+
+	if value, ok := tag.Lookup("envSeparator"); ok && value != "" && t.Separator == "" {
+		t.Separator = value
+	}
+	if value, ok := tag.Lookup("envKeyValSeparator"); ok && value != "" && t.KeyValueSeparator == "" {
+		t.KeyValueSeparator = value
+	}
+
+	if value, ok := tag.Lookup("required"); ok {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid tag 'required', expected a boolean, found '%s'", value)
+		}
+		t.Required = t.Required || required
+	}
+
+	return nil
+}
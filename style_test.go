@@ -0,0 +1,114 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+type styleConfig struct {
+	Host   string            `envconfig:"HOST" default:"localhost"`
+	Port   int               `envconfig:"PORT,default=80" envDefault:"8080"`
+	Hosts  []string          `envconfig:"HOSTS" envSeparator:";"`
+	Labels map[string]string `envconfig:"LABELS" envKeyValSeparator:":" envDefault:"app:web"`
+	Token  string            `envconfig:"TOKEN" required:"true"`
+	Skip   string            `env:"SKIP"`
+}
+
+func TestTagStyles(t *testing.T) {
+	tests := []struct {
+		name string
+		vars Map
+		opts []Option
+		want styleConfig
+		err  bool
+	}{
+		{
+			name: "tag name",
+			vars: Map{"HOST": "h", "PORT": "1", "HOSTS": "a,b", "LABELS": "k=v", "TOKEN": "t", "SKIP": "s"},
+			opts: []Option{WithTagName("envconfig")},
+			want: styleConfig{Host: "h", Port: 1, Hosts: []string{"a", "b"}, Labels: map[string]string{"k": "v"}, Token: "t"},
+		},
+		{
+			name: "companion tags",
+			vars: Map{"HOSTS": "a;b", "TOKEN": "t"},
+			opts: []Option{WithTagName("envconfig"), WithCompanionTags(), WithDefaultOptional()},
+			want: styleConfig{Host: "localhost", Port: 80, Hosts: []string{"a", "b"}, Labels: map[string]string{"app": "web"}, Token: "t"},
+		},
+		{
+			name: "companion required",
+			vars: Map{"HOSTS": "a;b"},
+			opts: []Option{WithTagName("envconfig"), WithCompanionTags(), WithDefaultOptional()},
+			err:  true,
+		},
+		{
+			name: "companion tags ignored by default",
+			vars: Map{"HOSTS": "a;b"},
+			opts: []Option{WithTagName("envconfig"), WithDefaultOptional()},
+			want: styleConfig{Port: 80, Hosts: []string{"a;b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg styleConfig
+			err := ParseWithOptions(&cfg, append([]Option{WithLookuper(tt.vars), WithHermetic()}, tt.opts...)...)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if err == nil && !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("expected %+v, found %+v", tt.want, cfg)
+			}
+		})
+	}
+}
+
+func TestCompanionTagsAutoNames(t *testing.T) {
+	var cfg struct {
+		Host    string `default:"localhost"`
+		MaxConn int    `envDefault:"10"`
+		Token   string `required:"false"`
+	}
+
+	opts := []Option{WithLookuper(Map{"MAX_CONN": "5"}), WithHermetic(), WithAutoNames(SnakeUpper), WithCompanionTags(), WithDefaultOptional()}
+	if err := ParseWithOptions(&cfg, opts...); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "localhost" || cfg.MaxConn != 5 || cfg.Token != "" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(struct {
+			Host string `env:"HOST" required:"yes please"`
+		}{}),
+		reflect.TypeOf(struct {
+			Host string `env:"HOST,optional" required:"true"`
+		}{}),
+	} {
+		err := ParseWithOptions(reflect.New(typ).Interface(), WithLookuper(Map{"HOST": "h"}), WithHermetic(), WithCompanionTags())
+		if err == nil {
+			t.Errorf("expected an error for %s", typ.Field(0).Tag)
+		}
+	}
+}
+
+func TestDescribeTagStyle(t *testing.T) {
+	specs, err := Describe(&styleConfig{}, WithTagName("envconfig"), WithCompanionTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]VarSpec{
+		"HOST":  {Name: "HOST", Field: "Host", Type: "string", Default: "localhost", Tag: "HOST"},
+		"PORT":  {Name: "PORT", Field: "Port", Type: "int", Default: "80", Tag: "PORT,default=80"},
+		"TOKEN": {Name: "TOKEN", Field: "Token", Type: "string", Required: true, Tag: "TOKEN"},
+	}
+	for _, spec := range specs {
+		if w, ok := want[spec.Name]; ok && !reflect.DeepEqual(spec, w) {
+			t.Errorf("expected %+v, found %+v", w, spec)
+		}
+	}
+	if len(specs) != 5 {
+		t.Errorf("expected 5 variables, found %+v", specs)
+	}
+}
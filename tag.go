@@ -0,0 +1,297 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnitBytes is the unit tag option for integer fields holding byte sizes,
+// accepting the same formats as ParseBytes.
+const UnitBytes = "bytes"
+
+// PathClean and PathAbs are the arguments of the pathlist tag option, which
+// clean the paths of a list or also make them absolute.
+const (
+	PathClean = "clean"
+	PathAbs   = "abs"
+)
+
+type Tag struct {
+	Env       string
+	Aliases   []string
+	Optional  bool
+	Required  bool
+	Default   string
+	Separator string
+
+	KeyValueSeparator string
+
+	// Deprecated holds former names, read after the name and aliases and
+	// reported through Options.OnDeprecated.
+	Deprecated []string
+
+	// From names the only source the value is read from, such as
+	// SourceEnv or the name of a NamedLookuper.
+	From string
+
+	Unit     string
+	Encoding string
+	Layout   string
+
+	// PathList splits lists on os.PathListSeparator, PathMode holds the
+	// argument of the option.
+	PathList bool
+	PathMode string
+
+	NotEmpty   bool
+	AllowEmpty bool
+	Expand     bool
+	File       bool
+	Secret     bool
+	Unset      bool
+	JSON       bool
+	NoTrim     bool
+	Keep       bool
+	Encrypted  bool
+
+	Description string
+
+	Rules []Rule
+
+	// RequiredIf holds the requiredIf and requiredWith conditions, which
+	// replace optional and required when present.
+	RequiredIf []Condition
+
+	// OnlyIf holds the onlyIf conditions of a nested struct, which is only
+	// parsed when all of them hold.
+	OnlyIf []Condition
+
+	// Unknown holds options that are not recognised. Parse ignores them,
+	// the envvet analyzer reports them.
+	Unknown []string
+}
+
+func (t Tag) Names() []string {
+	return append(append([]string{t.Env}, t.Aliases...), t.Deprecated...)
+}
+
+func (t Tag) isDeprecated(name string) bool {
+	for _, deprecated := range t.Deprecated {
+		if name == deprecated {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTag parses the env tag of a struct field the way Parse does, for tools
+// that work from source. It reports false if the field has no env tag.
+func ParseTag(tag reflect.StructTag) (Tag, bool, error) {
+	return parseTag(tag)
+}
+
+func parseTag(tag reflect.StructTag) (Tag, bool, error) {
+	return parseTagStyle(tag, tagStyle{})
+}
+
+func parseTagStyle(tag reflect.StructTag, style tagStyle) (Tag, bool, error) {
+	name := style.tagName()
+
+	raw, ok := tag.Lookup(name)
+	if !ok && style.companions {
+		// fields named by Options.AutoNames may only have companion tags
+		t := Tag{Description: tag.Get(DescTagName)}
+		return t, false, t.companions(tag)
+	}
+	if !ok {
+		return Tag{}, false, nil
+	}
+
+	parts := splitTag(raw)
+	if len(parts) == 0 {
+		return Tag{}, false, fmt.Errorf("empty tag '%s'", name)
+	}
+
+	names := strings.Split(parts[0], "|")
+
+	t := Tag{Env: names[0], Aliases: names[1:], Description: tag.Get(DescTagName)}
+	for _, alias := range t.Aliases {
+		if alias == "" {
+			return Tag{}, false, fmt.Errorf("empty alias in tag '%s'", name)
+		}
+	}
+	for _, value := range parts[1:] {
+		key, arg, hasArg := strings.Cut(value, "=")
+
+		switch key {
+		case "optional":
+			t.Optional = true
+
+		case "required":
+			t.Required = true
+
+		case "notEmpty":
+			t.NotEmpty = true
+
+		case "allowEmpty":
+			t.AllowEmpty = true
+
+		case "expand":
+			t.Expand = true
+
+		case "unset":
+			t.Unset = true
+
+		case "json":
+			t.JSON = true
+
+		case "noTrim":
+			t.NoTrim = true
+
+		case "keep":
+			t.Keep = true
+
+		case "encrypted":
+			t.Encrypted = true
+
+		case "file":
+			t.File = true
+
+		case "secret", "mask":
+			t.Secret = true
+
+		case "default":
+			if !hasArg {
+				return Tag{}, false, fmt.Errorf("invalid use of default in tag '%s', expected 'default=value', found '%s'", name, value)
+			}
+			t.Default = arg
+
+		case "separator", "sep":
+			if !hasArg || arg == "" {
+				return Tag{}, false, fmt.Errorf("invalid use of %s in tag '%s', expected '%s=value', found '%s'", key, name, key, value)
+			}
+			t.Separator = arg
+
+		case "deprecated":
+			if !hasArg || arg == "" {
+				return Tag{}, false, fmt.Errorf("invalid use of deprecated in tag '%s', expected 'deprecated=NAME', found '%s'", name, value)
+			}
+			t.Deprecated = append(t.Deprecated, arg)
+
+		case "from":
+			if !hasArg || arg == "" {
+				return Tag{}, false, fmt.Errorf("invalid use of from in tag '%s', expected 'from=source', found '%s'", name, value)
+			}
+			t.From = arg
+
+		case "kvSeparator", "kvsep":
+			if !hasArg || arg == "" {
+				return Tag{}, false, fmt.Errorf("invalid use of %s in tag '%s', expected '%s=value', found '%s'", key, name, key, value)
+			}
+			t.KeyValueSeparator = arg
+
+		case "encoding":
+			if !isEncoding(arg) {
+				return Tag{}, false, fmt.Errorf("invalid use of encoding in tag '%s', expected one of '%s', '%s' or '%s', found '%s'", name, EncodingBase64, EncodingBase64URL, EncodingHex, value)
+			}
+			t.Encoding = arg
+
+		case "layout":
+			if !hasArg || arg == "" {
+				return Tag{}, false, fmt.Errorf("invalid use of layout in tag '%s', expected 'layout=value', found '%s'", name, value)
+			}
+			t.Layout = arg
+
+		case "unit":
+			if arg != UnitBytes {
+				return Tag{}, false, fmt.Errorf("invalid use of unit in tag '%s', expected 'unit=%s', found '%s'", name, UnitBytes, value)
+			}
+			t.Unit = arg
+
+		case "pathlist":
+			if hasArg && arg != PathClean && arg != PathAbs {
+				return Tag{}, false, fmt.Errorf("invalid use of pathlist in tag '%s', expected 'pathlist', 'pathlist=%s' or 'pathlist=%s', found '%s'", name, PathClean, PathAbs, value)
+			}
+			t.PathList, t.PathMode = true, arg
+
+		case "requiredIf", "requiredWith":
+			c, err := newCondition(key, arg)
+			if err != nil {
+				return Tag{}, false, fmt.Errorf("invalid use of %s in tag '%s' : %w", key, name, err)
+			}
+			t.RequiredIf = append(t.RequiredIf, c)
+
+		case "onlyIf":
+			c, err := newCondition(key, arg)
+			if err != nil {
+				return Tag{}, false, fmt.Errorf("invalid use of %s in tag '%s' : %w", key, name, err)
+			}
+			t.OnlyIf = append(t.OnlyIf, c)
+
+		case "min", "max", "oneof", "match", "validate":
+			rule, err := newRule(key, arg, hasArg)
+			if err != nil {
+				return Tag{}, false, fmt.Errorf("invalid use of %s in tag '%s' : %w", key, name, err)
+			}
+			t.Rules = append(t.Rules, rule)
+
+		default:
+			t.Unknown = append(t.Unknown, key)
+		}
+	}
+
+	if style.companions {
+		if err := t.companions(tag); err != nil {
+			return Tag{}, false, err
+		}
+	}
+
+	if t.Optional && t.Required {
+		return Tag{}, false, fmt.Errorf("conflicting options 'optional' and 'required' in tag '%s'", name)
+	}
+	if t.Optional && t.Default != "" {
+		return Tag{}, false, fmt.Errorf("conflicting options 'optional' and 'default' in tag '%s'", name)
+	}
+	if t.Required && t.Default != "" {
+		return Tag{}, false, fmt.Errorf("conflicting options 'required' and 'default' in tag '%s'", name)
+	}
+	if t.NotEmpty && t.AllowEmpty {
+		return Tag{}, false, fmt.Errorf("conflicting options 'notEmpty' and 'allowEmpty' in tag '%s'", name)
+	}
+	if t.PathList && t.Separator != "" {
+		return Tag{}, false, fmt.Errorf("conflicting options 'pathlist' and 'separator' in tag '%s'", name)
+	}
+	if len(t.RequiredIf) > 0 && t.Optional {
+		return Tag{}, false, fmt.Errorf("conflicting options 'optional' and '%s' in tag '%s'", t.RequiredIf[0], name)
+	}
+	if len(t.RequiredIf) > 0 && t.Required {
+		return Tag{}, false, fmt.Errorf("conflicting options 'required' and '%s' in tag '%s'", t.RequiredIf[0], name)
+	}
+
+	return t, true, nil
+}
+
+// splitTag splits a tag on commas, a comma preceded by a backslash is kept as
+// part of the value.
+func splitTag(raw string) []string {
+	var parts []string
+
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == ',':
+			b.WriteByte(',')
+			i++
+
+		case raw[i] == ',':
+			parts = append(parts, b.String())
+			b.Reset()
+
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+
+	return append(parts, b.String())
+}
@@ -0,0 +1,122 @@
+package env
+
+import (
+	"reflect"
+)
+
+type Validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// validateStruct calls Validate on nested structs first and then on v itself.
+func (d *decoder) validateStruct(v reflect.Value, path string) []error {
+	errs := d.validateFields(v, path)
+
+	// the method would be called through a nil pointer
+	if promotedFromNil(v) {
+		return errs
+	}
+
+	if validator, ok := asValidator(v); ok {
+		if err := validator.Validate(); err != nil {
+			errs = append(errs, ValidationError{Field: path, Err: err})
+		}
+	}
+
+	return errs
+}
+
+// validateFields validates the nested structs of v. Embedded structs whose
+// Validate method v has, promoted or shadowed by its own, only get their
+// fields validated: like a call to v.Validate, the method of v runs once.
+func (d *decoder) validateFields(v reflect.Value, path string) []error {
+	var errs []error
+	for i, f := range fieldsOf(v.Type(), d.opts.tagStyle()) {
+		tField := f.StructField
+		vField := v.Field(i)
+
+		switch d.opts.classify(f) {
+		case fieldNested:
+			if d.inactive != nil && d.inactive[joinPath(path, tField.Name)] {
+				continue
+			}
+
+			nestedPath := path
+			if !tField.Anonymous {
+				nestedPath = joinPath(path, tField.Name)
+			}
+
+			nested, ok := structValue(vField)
+			if !ok {
+				continue
+			}
+			if tField.Anonymous && providesValidate(v.Type()) && providesValidate(tField.Type) {
+				errs = append(errs, d.validateFields(nested, nestedPath)...)
+			} else {
+				errs = append(errs, d.validateStruct(nested, nestedPath)...)
+			}
+
+		case fieldIndexed:
+			for j := 0; j < vField.Len(); j++ {
+				if nested, ok := structValue(vField.Index(j)); ok {
+					errs = append(errs, d.validateStruct(nested, indexPath(path, tField.Name, j))...)
+				}
+			}
+
+		case fieldKeyed:
+			for _, key := range sortedKeys(vField) {
+				// map elements are not addressable, so they are validated as
+				// a copy that is stored back for Validate methods setting
+				// fields
+				elem := reflect.New(vField.Type().Elem()).Elem()
+				elem.Set(vField.MapIndex(key))
+
+				if nested, ok := structValue(elem); ok {
+					errs = append(errs, d.validateStruct(nested, joinPath(path, tField.Name)+"["+key.String()+"]")...)
+					vField.SetMapIndex(key, elem)
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+func asValidator(v reflect.Value) (Validator, bool) {
+	// unexported embedded structs cannot be used as interfaces, their
+	// Validate method is promoted to the enclosing struct instead
+	if !v.CanInterface() {
+		return nil, false
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(validatorType) {
+		return v.Addr().Interface().(Validator), true
+	}
+
+	if v.Type().Implements(validatorType) {
+		return v.Interface().(Validator), true
+	}
+
+	return nil, false
+}
+
+func providesValidate(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(validatorType)
+}
+
+// promotedFromNil reports whether v embeds a nil pointer to a struct
+// providing Validate, which v would then have promoted from it.
+func promotedFromNil(v reflect.Value) bool {
+	for i := 0; i < v.NumField(); i++ {
+		tField := v.Type().Field(i)
+		if tField.Anonymous && tField.Type.Kind() == reflect.Ptr && providesValidate(tField.Type) && v.Field(i).IsNil() {
+			return true
+		}
+	}
+	return false
+}
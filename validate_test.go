@@ -0,0 +1,162 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var validateCalls []string
+
+// ValidValue and ValidPointer are exported so that structs embedding them can
+// call their Validate methods directly.
+type ValidValue struct {
+	Name string `env:"NAME,optional"`
+}
+
+func (v ValidValue) Validate() error {
+	validateCalls = append(validateCalls, "ValidValue")
+	return nil
+}
+
+type ValidPointer struct {
+	Name string `env:"NAME,optional"`
+	Seen bool   `env:"-"`
+}
+
+func (v *ValidPointer) Validate() error {
+	validateCalls = append(validateCalls, "ValidPointer "+v.Name)
+	v.Seen = true
+	if v.Name == "invalid" {
+		return errors.New("invalid name")
+	}
+	return nil
+}
+
+type validUnexported struct {
+	Name string `env:"NAME,optional"`
+}
+
+func (v validUnexported) Validate() error {
+	validateCalls = append(validateCalls, "validUnexported")
+	return nil
+}
+
+type validPromotedValue struct {
+	ValidValue
+}
+
+type validPromotedPointer struct {
+	ValidPointer
+}
+
+type validPromotedNil struct {
+	*ValidPointer
+}
+
+type validShadowed struct {
+	ValidValue
+}
+
+func (validShadowed) Validate() error {
+	validateCalls = append(validateCalls, "validShadowed")
+	return nil
+}
+
+type validShadowedPointer struct {
+	ValidPointer
+}
+
+func (v *validShadowedPointer) Validate() error {
+	if err := v.ValidPointer.Validate(); err != nil {
+		return err
+	}
+	validateCalls = append(validateCalls, "validShadowedPointer")
+	return nil
+}
+
+type validEmbedsUnexported struct {
+	validUnexported
+}
+
+// ValidOther is exported so that its Validate method can be called when it is
+// not promoted.
+type ValidOther struct{}
+
+func (ValidOther) Validate() error {
+	validateCalls = append(validateCalls, "ValidOther")
+	return nil
+}
+
+// validAmbiguous has no Validate method, the two embedded ones conflict.
+type validAmbiguous struct {
+	ValidValue
+	ValidOther
+}
+
+type validMap struct {
+	Items map[string]ValidPointer `envPrefix:"ITEM_"`
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		obj   interface{}
+		vars  Map
+		calls []string
+		err   string
+	}{
+		{name: "value", obj: &ValidValue{}, calls: []string{"ValidValue"}},
+		{name: "pointer", obj: &ValidPointer{}, vars: Map{"NAME": "a"}, calls: []string{"ValidPointer a"}},
+		{name: "promoted value", obj: &validPromotedValue{}, calls: []string{"ValidValue"}},
+		{name: "promoted pointer", obj: &validPromotedPointer{}, calls: []string{"ValidPointer "}},
+		{name: "promoted through nil pointer", obj: &validPromotedNil{}},
+		{name: "shadowed", obj: &validShadowed{}, calls: []string{"validShadowed"}},
+		{name: "shadowed pointer", obj: &validShadowedPointer{}, calls: []string{"ValidPointer ", "validShadowedPointer"}},
+		{name: "unexported embedded", obj: &validEmbedsUnexported{}, calls: []string{"validUnexported"}},
+		{name: "ambiguous", obj: &validAmbiguous{}, calls: []string{"ValidValue", "ValidOther"}},
+		{
+			name:  "error",
+			obj:   &ValidPointer{},
+			vars:  Map{"NAME": "invalid"},
+			calls: []string{"ValidPointer invalid"},
+			err:   "validation failed : invalid name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validateCalls = nil
+
+			err := ParseWithOptions(tt.obj, WithLookuper(tt.vars), WithHermetic())
+			if tt.err == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("expected error %q, found %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(validateCalls, tt.calls) {
+				t.Errorf("expected calls %q, found %q", tt.calls, validateCalls)
+			}
+		})
+	}
+}
+
+func TestValidateMapElements(t *testing.T) {
+	validateCalls = nil
+
+	var cfg validMap
+	err := ParseWithOptions(&cfg, WithLookuper(Map{"ITEM_A_NAME": "a", "ITEM_B_NAME": "b"}), WithHermetic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"ValidPointer a", "ValidPointer b"}; !reflect.DeepEqual(validateCalls, want) {
+		t.Errorf("expected calls %q, found %q", want, validateCalls)
+	}
+	for key, item := range cfg.Items {
+		if !item.Seen {
+			t.Errorf("expected the changes of Validate to be kept for key %q", key)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Package vault resolves variables from a HashiCorp Vault KV v2 secret using
+// Vault's HTTP API.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Auth interface {
+	Login(ctx context.Context, client *http.Client, addr string) (token string, ttl time.Duration, err error)
+}
+
+type tokenAuth string
+
+// Token authenticates with a static token, which is never renewed.
+func Token(token string) Auth {
+	return tokenAuth(token)
+}
+
+func (t tokenAuth) Login(context.Context, *http.Client, string) (string, time.Duration, error) {
+	return string(t), 0, nil
+}
+
+type appRoleAuth struct {
+	mount    string
+	roleID   string
+	secretID string
+}
+
+// AppRole logs in with the approle auth method mounted at "approle".
+func AppRole(roleID, secretID string) Auth {
+	return appRoleAuth{mount: "approle", roleID: roleID, secretID: secretID}
+}
+
+func (a appRoleAuth) Login(ctx context.Context, client *http.Client, addr string) (string, time.Duration, error) {
+	body, err := json.Marshal(map[string]string{
+		"role_id":   a.roleID,
+		"secret_id": a.secretID,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr+"/v1/auth/"+a.mount+"/login", bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+
+	var res struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+
+	if _, err := do(client, req, &res); err != nil {
+		return "", 0, fmt.Errorf("error logging in with approle : %w", err)
+	}
+
+	return res.Auth.ClientToken, time.Duration(res.Auth.LeaseDuration) * time.Second, nil
+}
+
+type options struct {
+	mount     string
+	namespace string
+	client    *http.Client
+	ttl       time.Duration
+	mapName   func(key string) string
+}
+
+type Option func(*options)
+
+// WithMount sets the KV v2 engine mount, defaults to "secret".
+func WithMount(mount string) Option {
+	return func(o *options) {
+		o.mount = mount
+	}
+}
+
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithTTL sets how long the secret is cached, defaults to five minutes.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithNameMapper maps a variable name to a key inside the secret.
+func WithNameMapper(fn func(key string) string) Option {
+	return func(o *options) {
+		o.mapName = fn
+	}
+}
+
+type Source struct {
+	addr string
+	path string
+	auth Auth
+	opts options
+
+	mu       sync.Mutex
+	token    string
+	tokenExp time.Time
+	data     map[string]string
+	dataExp  time.Time
+}
+
+func New(addr, path string, auth Auth, opts ...Option) *Source {
+	o := options{
+		mount:  "secret",
+		client: http.DefaultClient,
+		ttl:    5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.namespace != "" {
+		client := *o.client
+		if client.Transport == nil {
+			client.Transport = http.DefaultTransport
+		}
+		client.Transport = namespaceTransport{namespace: o.namespace, next: client.Transport}
+		o.client = &client
+	}
+
+	return &Source{
+		addr: strings.TrimSuffix(addr, "/"),
+		path: strings.Trim(path, "/"),
+		auth: auth,
+		opts: o,
+	}
+}
+
+func (s *Source) Lookup(key string) (string, bool) {
+	value, ok, _ := s.LookupContext(context.Background(), key)
+	return value, ok
+}
+
+func (s *Source) LookupContext(ctx context.Context, key string) (string, bool, error) {
+	data, err := s.secret(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	if s.opts.mapName != nil {
+		key = s.opts.mapName(key)
+	}
+
+	value, ok := data[key]
+	return value, ok, nil
+}
+
+func (s *Source) SourceName() string {
+	return "vault"
+}
+
+func (s *Source) secret(ctx context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.data != nil && now.Before(s.dataExp) {
+		return s.data, nil
+	}
+
+	if s.token == "" || (!s.tokenExp.IsZero() && !now.Before(s.tokenExp)) {
+		token, ttl, err := s.auth.Login(ctx, s.opts.client, s.addr)
+		if err != nil {
+			return nil, err
+		}
+
+		s.token, s.tokenExp = token, time.Time{}
+		if ttl > 0 {
+			s.tokenExp = now.Add(ttl)
+		}
+	}
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.data, s.dataExp = data, now.Add(s.opts.ttl)
+	return data, nil
+}
+
+func (s *Source) read(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.addr+"/v1/"+s.opts.mount+"/data/"+s.path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Vault-Token", s.token)
+
+	var res struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+
+	status, err := do(s.opts.client, req, &res)
+	if status == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading secret '%s' : %w", s.path, err)
+	}
+
+	data := make(map[string]string, len(res.Data.Data))
+	for key, value := range res.Data.Data {
+		switch v := value.(type) {
+		case string:
+			data[key] = v
+
+		default:
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding key '%s' : %w", key, err)
+			}
+			data[key] = string(raw)
+		}
+	}
+
+	return data, nil
+}
+
+func do(client *http.Client, req *http.Request, v interface{}) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d : %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return resp.StatusCode, json.Unmarshal(body, v)
+}
+
+type namespaceTransport struct {
+	namespace string
+	next      http.RoundTripper
+}
+
+func (t namespaceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("X-Vault-Namespace", t.namespace)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,116 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type server struct {
+	*httptest.Server
+	logins, reads int32
+}
+
+func newServer(t *testing.T) *server {
+	s := &server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/auth/approle/login":
+			atomic.AddInt32(&s.logins, 1)
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["role_id"] != "role" || body["secret_id"] != "secret" {
+				http.Error(w, "invalid credentials", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"auth": {"client_token": "approle-token", "lease_duration": 3600}}`))
+
+		case r.Header.Get("X-Vault-Token") != "token" && r.Header.Get("X-Vault-Token") != "approle-token":
+			http.Error(w, "permission denied", http.StatusForbidden)
+
+		case r.URL.Path == "/v1/kv/data/myapp" && r.Header.Get("X-Vault-Namespace") != "team":
+			http.Error(w, "wrong namespace", http.StatusForbidden)
+
+		case r.URL.Path == "/v1/secret/data/myapp" || r.URL.Path == "/v1/kv/data/myapp":
+			atomic.AddInt32(&s.reads, 1)
+			w.Write([]byte(`{"data": {"data": {"DB_PASSWORD": "hunter2", "db_user": "app", "PORT": 5432}}}`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		auth Auth
+		opts []Option
+		key  string
+		want string
+		ok   bool
+		err  bool
+	}{
+		{name: "token", path: "myapp", auth: Token("token"), key: "DB_PASSWORD", want: "hunter2", ok: true},
+		{name: "approle", path: "/myapp/", auth: AppRole("role", "secret"), key: "DB_PASSWORD", want: "hunter2", ok: true},
+		{name: "non string values", path: "myapp", auth: Token("token"), key: "PORT", want: "5432", ok: true},
+		{name: "name mapper", path: "myapp", auth: Token("token"), opts: []Option{WithNameMapper(strings.ToLower)}, key: "DB_USER", want: "app", ok: true},
+		{name: "mount and namespace", path: "myapp", auth: Token("token"), opts: []Option{WithMount("kv"), WithNamespace("team")}, key: "DB_PASSWORD", want: "hunter2", ok: true},
+		{name: "missing key", path: "myapp", auth: Token("token"), key: "MISSING"},
+		{name: "missing secret", path: "other", auth: Token("token"), key: "DB_PASSWORD"},
+		{name: "bad token", path: "myapp", auth: Token("bad"), key: "DB_PASSWORD", err: true},
+		{name: "bad approle", path: "myapp", auth: AppRole("role", "wrong"), key: "DB_PASSWORD", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t)
+			s := New(srv.URL+"/", tt.path, tt.auth, tt.opts...)
+
+			value, ok, err := s.LookupContext(context.Background(), tt.key)
+			if (err != nil) != tt.err {
+				t.Fatalf("expected error %v, found %v", tt.err, err)
+			}
+			if value != tt.want || ok != tt.ok {
+				t.Errorf("expected '%s', %v, found '%s', %v", tt.want, tt.ok, value, ok)
+			}
+		})
+	}
+}
+
+func TestSourceCaching(t *testing.T) {
+	srv := newServer(t)
+	s := New(srv.URL, "myapp", AppRole("role", "secret"))
+
+	for _, key := range []string{"DB_PASSWORD", "PORT", "MISSING"} {
+		if _, _, err := s.LookupContext(context.Background(), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if logins, reads := atomic.LoadInt32(&srv.logins), atomic.LoadInt32(&srv.reads); logins != 1 || reads != 1 {
+		t.Errorf("expected 1 login and 1 read, found %d and %d", logins, reads)
+	}
+}
+
+func TestSourceTTL(t *testing.T) {
+	srv := newServer(t)
+	s := New(srv.URL, "myapp", AppRole("role", "secret"), WithTTL(0))
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := s.LookupContext(context.Background(), "DB_PASSWORD"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the secret expires at once but the token is still valid
+	if logins, reads := atomic.LoadInt32(&srv.logins), atomic.LoadInt32(&srv.reads); logins != 1 || reads != 2 {
+		t.Errorf("expected 1 login and 2 reads, found %d and %d", logins, reads)
+	}
+}
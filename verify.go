@@ -0,0 +1,220 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteSchema writes the output of Describe as JSON, for programs such as
+// envcheck that verify an environment without the Go types at hand.
+func WriteSchema(w io.Writer, obj interface{}, opts ...Option) error {
+	specs, err := Describe(obj, opts...)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(specs)
+}
+
+func ReadSchema(r io.Reader) ([]VarSpec, error) {
+	var specs []VarSpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// Verify checks that the variables described by specs are present and parse
+// into their types, returning the same errors Parse would. With WithStrict it
+// also reports unknown variables under the prefix. Types that are not known
+// by name, such as those of the program the specs came from, are only
+// checked for presence and with rules that do not depend on the type.
+// Variables of indexed and keyed fields, whose names hold placeholders, are
+// not checked.
+func Verify(specs []VarSpec, opts ...Option) error {
+	o := newOptions(opts)
+
+	if err := o.load(); err != nil {
+		return err
+	}
+
+	d := newDecoder(context.Background(), o)
+
+	var errs []error
+	for _, spec := range specs {
+		if spec.Pattern {
+			continue
+		}
+
+		tag, _, err := parseTag(reflect.StructTag(TagName + ":" + strconv.Quote(spec.Tag)))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error parsing tag of field '%s' : %w", spec.Field, err))
+			continue
+		}
+
+		tag.Env, tag.Aliases, tag.Deprecated = spec.Name, spec.Aliases, spec.Deprecated
+		tag.Description = spec.Description
+		if tag.Default == "" {
+			// defaults may come from companion tags or another tag name
+			tag.Default = spec.Default
+		}
+
+		// the spec says whether the variable is required with the options
+		// it was described with, such as WithDefaultOptional, which the
+		// tag alone does not
+		tag.Optional, tag.Required = !spec.Required, spec.Required
+
+		if len(spec.RequiredIf) > 0 {
+			tag.Optional, tag.Required, tag.RequiredIf = false, false, nil
+			for _, raw := range spec.RequiredIf {
+				c, err := parseCondition(raw)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("error parsing schema of field '%s' : %w", spec.Field, err))
+					continue
+				}
+				tag.RequiredIf = append(tag.RequiredIf, c)
+			}
+		}
+
+		// like the structs they come from, variables gated by onlyIf are
+		// only checked when all of the conditions hold
+		var onlyIf []Condition
+		for _, raw := range spec.OnlyIf {
+			c, err := parseCondition(raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error parsing schema of field '%s' : %w", spec.Field, err))
+				continue
+			}
+			onlyIf = append(onlyIf, c)
+		}
+		active, err := d.holds(onlyIf, "")
+		if err != nil {
+			errs = append(errs, LookupError{Env: onlyIf[0].Env, Field: spec.Field, Err: err})
+			continue
+		}
+		if !active {
+			for _, name := range tag.Names() {
+				d.consume(name)
+			}
+			continue
+		}
+
+		t, ok := o.typeByName(spec.Type)
+		if !ok {
+			t = reflect.TypeOf("")
+
+			var rules []Rule
+			for _, rule := range tag.Rules {
+				if rule.Name != "min" && rule.Name != "max" {
+					rules = append(rules, rule)
+				}
+			}
+			tag.Rules = rules
+		}
+
+		f := field{StructField: reflect.StructField{Name: spec.Field, Type: t}, tag: tag}
+		if err := d.parseField(f, reflect.New(t).Elem(), scope{}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if o.Strict {
+		errs = append(errs, d.unknown()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+var namedTypes = map[string]reflect.Type{
+	"string":        reflect.TypeOf(""),
+	"bool":          reflect.TypeOf(false),
+	"int":           reflect.TypeOf(int(0)),
+	"int8":          reflect.TypeOf(int8(0)),
+	"int16":         reflect.TypeOf(int16(0)),
+	"int32":         reflect.TypeOf(int32(0)),
+	"int64":         reflect.TypeOf(int64(0)),
+	"uint":          reflect.TypeOf(uint(0)),
+	"uint8":         reflect.TypeOf(uint8(0)),
+	"uint16":        reflect.TypeOf(uint16(0)),
+	"uint32":        reflect.TypeOf(uint32(0)),
+	"uint64":        reflect.TypeOf(uint64(0)),
+	"float32":       reflect.TypeOf(float32(0)),
+	"float64":       reflect.TypeOf(float64(0)),
+	"time.Duration": durationType,
+	"time.Time":     reflect.TypeOf(time.Time{}),
+	"url.URL":       urlType,
+	"net.IP":        reflect.TypeOf(net.IP{}),
+	"net.IPNet":     ipNetType,
+	"netip.Addr":    reflect.TypeOf(netip.Addr{}),
+	"netip.Prefix":  reflect.TypeOf(netip.Prefix{}),
+	"env.Bytes":     reflect.TypeOf(Bytes(0)),
+	"big.Int":       bigIntType,
+	"big.Float":     bigFloatType,
+}
+
+// typeByName resolves a type as written by reflect.Type.String, covering
+// basic and common library types, types with registered parsers and
+// pointers, slices and maps of those.
+func (o Options) typeByName(name string) (reflect.Type, bool) {
+	if t, ok := namedTypes[name]; ok {
+		return t, true
+	}
+
+	for t := range o.Parsers {
+		if t.String() == name {
+			return t, true
+		}
+	}
+
+	parsersMu.RLock()
+	for t := range parsers {
+		if t.String() == name {
+			parsersMu.RUnlock()
+			return t, true
+		}
+	}
+	parsersMu.RUnlock()
+
+	switch {
+	case strings.HasPrefix(name, "*"):
+		if elem, ok := o.typeByName(name[1:]); ok {
+			return reflect.PtrTo(elem), true
+		}
+
+	case strings.HasPrefix(name, "[]"):
+		if elem, ok := o.typeByName(name[2:]); ok {
+			return reflect.SliceOf(elem), true
+		}
+
+	case strings.HasPrefix(name, "["):
+		if i := strings.Index(name, "]"); i > 0 {
+			n, err := strconv.Atoi(name[1:i])
+			elem, ok := o.typeByName(name[i+1:])
+			if err == nil && n >= 0 && ok {
+				return reflect.ArrayOf(n, elem), true
+			}
+		}
+
+	case strings.HasPrefix(name, "map["):
+		if i := strings.Index(name, "]"); i > 0 {
+			key, okKey := o.typeByName(name[4:i])
+			elem, okElem := o.typeByName(name[i+1:])
+			if okKey && okElem {
+				return reflect.MapOf(key, elem), true
+			}
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,368 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+const (
+	fieldSkip = iota
+	fieldNested
+	fieldIndexed
+	fieldKeyed
+	fieldValue
+)
+
+func (o Options) classify(f field) int {
+	tField := f.StructField
+	raw, ok := f.raw, f.hasTag
+
+	if !tField.IsExported() {
+		// the exported fields of an unexported embedded struct are promoted
+		// and remain settable, so the struct is flattened like any other
+		if tField.Anonymous && !ok && tField.Type.Kind() == reflect.Struct && !o.isValueType(tField.Type) {
+			return fieldNested
+		}
+		return fieldSkip
+	}
+
+	switch {
+	case raw == "-":
+		return fieldSkip
+
+	case ok && f.tag.Env == "" && len(f.tag.OnlyIf) > 0 && isStruct(tField.Type) && !o.isValueType(tField.Type):
+		return fieldNested
+
+	case ok:
+		return fieldValue
+
+	case isStruct(tField.Type) && !o.isValueType(tField.Type):
+		return fieldNested
+
+	case f.hasPrefix && o.isIndexed(tField):
+		return fieldIndexed
+
+	case f.hasPrefix && o.isKeyed(tField):
+		return fieldKeyed
+
+	case o.AutoNames != nil:
+		return fieldValue
+	}
+
+	return fieldSkip
+}
+
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// isValueType reports whether a struct type is converted as a whole, such as
+// time.Time or a Setter, rather than being descended into.
+func (o Options) isValueType(t reflect.Type) bool {
+	if _, ok := o.parser(t); ok {
+		return true
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if _, ok := o.parser(t); ok {
+		return true
+	}
+
+	return implementsSetter(t) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// isIndexed reports whether a field is a slice of structs read from numbered
+// variables under its envPrefix.
+func (o Options) isIndexed(tField reflect.StructField) bool {
+	if _, ok := tField.Tag.Lookup(PrefixTagName); !ok {
+		return false
+	}
+
+	t := tField.Type
+	return t.Kind() == reflect.Slice && isStruct(t.Elem()) && !o.isValueType(t.Elem())
+}
+
+// isKeyed reports whether a field is a map of structs read from variables
+// named <PREFIX><KEY>_<NAME> under its envPrefix.
+func (o Options) isKeyed(tField reflect.StructField) bool {
+	if _, ok := tField.Tag.Lookup(PrefixTagName); !ok {
+		return false
+	}
+
+	t := tField.Type
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && isStruct(t.Elem()) && !o.isValueType(t.Elem())
+}
+
+// scope carries naming state down through nested structs.
+type scope struct {
+	prefix string
+	path   string
+
+	// auto holds the field names since the last explicit prefix, used to
+	// derive variable names with Options.AutoNames.
+	auto []string
+
+	// onlyIf holds the onlyIf conditions of the enclosing structs.
+	onlyIf []Condition
+
+	// pattern is set inside the elements of indexed and keyed fields
+	// described by their type, whose names hold a placeholder.
+	pattern bool
+
+	// parents holds the struct types being descended into, so zero values
+	// of recursive types are not allocated without end.
+	parents []reflect.Type
+}
+
+// The placeholders stand for the index or key in the names of variables of
+// indexed and keyed fields that are described by their element type.
+const (
+	indexPlaceholder = "<n>"
+	keyPlaceholder   = "<key>"
+)
+
+func (s scope) nested(tField reflect.StructField) scope {
+	n := scope{prefix: s.prefix, path: s.path, auto: s.auto, onlyIf: s.onlyIf, pattern: s.pattern, parents: s.parents}
+
+	if !tField.Anonymous {
+		n.path = joinPath(s.path, tField.Name)
+	}
+
+	if prefix, ok := tField.Tag.Lookup(PrefixTagName); ok {
+		n.prefix += prefix
+		n.auto = nil
+	} else if !tField.Anonymous {
+		n.auto = append(append([]string(nil), s.auto...), tField.Name)
+	}
+
+	return n
+}
+
+// index returns the scope of element i of an indexed slice field.
+func (s scope) index(tField reflect.StructField, i int) scope {
+	return scope{
+		prefix:  s.prefix + tField.Tag.Get(PrefixTagName) + strconv.Itoa(i) + "_",
+		path:    indexPath(s.path, tField.Name, i),
+		onlyIf:  s.onlyIf,
+		pattern: s.pattern,
+		parents: s.parents,
+	}
+}
+
+// key returns the scope of the element stored under key in a keyed map field.
+func (s scope) key(tField reflect.StructField, key string) scope {
+	return scope{
+		prefix:  s.prefix + tField.Tag.Get(PrefixTagName) + key + "_",
+		path:    joinPath(s.path, tField.Name) + "[" + key + "]",
+		onlyIf:  s.onlyIf,
+		pattern: s.pattern,
+		parents: s.parents,
+	}
+}
+
+// element returns the scope of any element of an indexed or keyed field,
+// named with placeholder in place of the index or key.
+func (s scope) element(tField reflect.StructField, placeholder string) scope {
+	n := s.key(tField, placeholder)
+	n.pattern = true
+	return n
+}
+
+// enter returns the scope for the fields of a struct of type t.
+func (s scope) enter(t reflect.Type) scope {
+	s.parents = append(s.parents[:len(s.parents):len(s.parents)], t)
+	return s
+}
+
+// recursive reports whether t, or the struct t points to, is already being
+// descended into. A zero value of such a type would hold the same fields
+// again, so it is not allocated.
+func (s scope) recursive(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, parent := range s.parents {
+		if parent == t {
+			return true
+		}
+	}
+	return false
+}
+
+// tag resolves the variable names of f within the scope. The cached tag is
+// shared, so the aliases and deprecated names are copied before being
+// prefixed.
+func (s scope) tag(f field, opts Options) (Tag, error) {
+	if f.tagErr != nil {
+		return Tag{}, f.tagErr
+	}
+
+	// conditions gate nested structs only, a value is read or not by its
+	// own tag
+	if len(f.tag.OnlyIf) > 0 {
+		return Tag{}, fmt.Errorf("onlyIf is only supported on nested structs")
+	}
+
+	tag := f.tag
+	if tag.Env == "" && opts.AutoNames != nil {
+		tag.Env = opts.AutoNames(append(append([]string(nil), s.auto...), f.Name))
+	}
+
+	if tag.Env == "" {
+		return Tag{}, fmt.Errorf("missing variable name in tag '%s'", opts.tagStyle().tagName())
+	}
+
+	tag.Env = opts.rename(s.prefix + tag.Env)
+	tag.Aliases = nil
+	for _, name := range f.tag.Aliases {
+		tag.Aliases = append(tag.Aliases, opts.rename(s.prefix+name))
+	}
+	if opts.LegacyNames != nil {
+		legacy := opts.rename(s.prefix + opts.LegacyNames(append(append([]string(nil), s.auto...), f.Name)))
+		if legacy != tag.Env && !contains(tag.Aliases, legacy) {
+			tag.Aliases = append(tag.Aliases, legacy)
+		}
+	}
+	tag.Deprecated = nil
+	for _, name := range f.tag.Deprecated {
+		tag.Deprecated = append(tag.Deprecated, opts.rename(s.prefix+name))
+	}
+
+	return tag, nil
+}
+
+type FieldInfo struct {
+	Path string
+	Env  string
+	Type reflect.Type
+	Tag  Tag
+}
+
+type fieldInfo struct {
+	path   string
+	tag    Tag
+	field  reflect.StructField
+	value  reflect.Value
+	onlyIf []Condition
+
+	// pattern is set for variables of indexed and keyed fields described by
+	// their element type, see walkAll.
+	pattern bool
+}
+
+// walk visits every field reachable from v the same way parsing does, without
+// modifying anything. Nil struct pointers are not descended into.
+func walk(v reflect.Value, opts Options, fn func(fieldInfo) error) error {
+	return walkStruct(v, scope{prefix: opts.Prefix}, opts, false, fn)
+}
+
+// walkAll is like walk but descends into nil struct pointers using zero
+// values, visiting every field the type could consume. Indexed and keyed
+// fields are visited once through a zero element, whatever they hold, with
+// indexPlaceholder or keyPlaceholder in place of the index or key.
+func walkAll(v reflect.Value, opts Options, fn func(fieldInfo) error) error {
+	return walkStruct(v, scope{prefix: opts.Prefix}, opts, true, fn)
+}
+
+func walkStruct(v reflect.Value, s scope, opts Options, all bool, fn func(fieldInfo) error) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !all || s.recursive(v.Type().Elem()) {
+				return nil
+			}
+			v = reflect.New(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+
+	s = s.enter(v.Type())
+	for i, f := range fieldsOf(v.Type(), opts.tagStyle()) {
+		tField := f.StructField
+		vField := v.Field(i)
+
+		switch opts.classify(f) {
+		case fieldNested:
+			n := s.nested(tField)
+			n.onlyIf = append(append([]Condition(nil), s.onlyIf...), f.tag.OnlyIf...)
+			if err := walkStruct(vField, n, opts, all, fn); err != nil {
+				return err
+			}
+
+		case fieldIndexed:
+			if all {
+				if s.recursive(tField.Type.Elem()) {
+					continue
+				}
+				elem := reflect.New(tField.Type.Elem()).Elem()
+				if err := walkStruct(elem, s.element(tField, indexPlaceholder), opts, all, fn); err != nil {
+					return err
+				}
+				continue
+			}
+
+			for i := 0; i < vField.Len(); i++ {
+				if err := walkStruct(vField.Index(i), s.index(tField, i), opts, all, fn); err != nil {
+					return err
+				}
+			}
+
+		case fieldKeyed:
+			if all {
+				if s.recursive(tField.Type.Elem()) {
+					continue
+				}
+				elem := reflect.New(tField.Type.Elem()).Elem()
+				if err := walkStruct(elem, s.element(tField, keyPlaceholder), opts, all, fn); err != nil {
+					return err
+				}
+				continue
+			}
+
+			for _, key := range sortedKeys(vField) {
+				if err := walkStruct(vField.MapIndex(key), s.key(tField, key.String()), opts, all, fn); err != nil {
+					return err
+				}
+			}
+
+		case fieldValue:
+			path := joinPath(s.path, tField.Name)
+
+			tag, err := s.tag(f, opts)
+			if err != nil {
+				return fmt.Errorf("error parsing tag of field '%s' : %w", path, err)
+			}
+
+			if err := fn(fieldInfo{path: path, tag: tag, field: tField, value: vField, onlyIf: s.onlyIf, pattern: s.pattern}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+func indexPath(path, name string, i int) string {
+	return joinPath(path, name) + "[" + strconv.Itoa(i) + "]"
+}
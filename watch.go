@@ -0,0 +1,115 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Change is a field whose value differs between two configurations. The
+// values of secret fields, such as those read from files or decrypted, are
+// Redacted.
+type Change struct {
+	Field string
+	Env   string
+	Old   interface{}
+	New   interface{}
+}
+
+type Diff []Change
+
+// Watch parses cfg with opts, then reparses it every interval until ctx is
+// done, replacing *cfg and calling onChange after a reload that changed any
+// field. After a failed reload, onChange gets the error and cfg is kept.
+// Like a Holder, which Watch uses, each reload starts from the zero value.
+// cfg is only written by Watch between calls to onChange, goroutines reading
+// it concurrently should use a Holder instead.
+func Watch[T any](ctx context.Context, cfg *T, interval time.Duration, onChange func(Diff, error), opts ...Option) error {
+	h, err := NewHolder[T](opts...)
+	if err != nil {
+		return err
+	}
+	*cfg = *h.Load()
+
+	return h.Watch(ctx, interval, func(diff Diff, err error) {
+		if err == nil {
+			*cfg = *h.Load()
+		}
+		onChange(diff, err)
+	})
+}
+
+// Watch reloads h every interval until ctx is done. Like ReloadOnSignal,
+// onChange is called after a reload that changed any field or failed, with
+// the changes or the error, in which case the current configuration is kept.
+// Readers see the new configuration through Load.
+func (h *Holder[T]) Watch(ctx context.Context, interval time.Duration, onChange func(Diff, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			diff, err := h.reload(ctx)
+			if err != nil || len(diff) > 0 {
+				onChange(diff, err)
+			}
+		}
+	}
+}
+
+// diffValues compares the fields of old and next. secrets holds the paths of
+// fields whose values were secret in either of them, beyond those the tags
+// mark as secret.
+func diffValues(old, next reflect.Value, opts Options, secrets map[string]bool) (Diff, error) {
+	var removed []fieldInfo
+	values := map[string]interface{}{}
+	if err := walk(old, opts, func(f fieldInfo) error {
+		values[f.path] = f.value.Interface()
+		removed = append(removed, f)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var diff Diff
+	seen := map[string]bool{}
+	if err := walk(next, opts, func(f fieldInfo) error {
+		seen[f.path] = true
+
+		value := f.value.Interface()
+		if prev, ok := values[f.path]; !ok || !reflect.DeepEqual(prev, value) {
+			diff = append(diff, change(f, secrets, prev, value))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, f := range removed {
+		if !seen[f.path] {
+			diff = append(diff, change(f, secrets, values[f.path], nil))
+		}
+	}
+
+	return diff, nil
+}
+
+func change(f fieldInfo, secrets map[string]bool, old, next interface{}) Change {
+	if isSecret(f.tag, "") || secrets[f.path] {
+		old, next = redactValue(old), redactValue(next)
+	}
+	return Change{Field: f.path, Env: f.tag.Env, Old: old, New: next}
+}
+
+// redactValue hides a value, leaving missing and zero values visible like
+// redact does for empty strings.
+func redactValue(v interface{}) interface{} {
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return v
+	}
+	return Redacted
+}
@@ -0,0 +1,232 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type watchConfig struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT"`
+}
+
+// watchVars is a Lookuper whose variables can change while Watch reads them.
+type watchVars struct {
+	mu   sync.Mutex
+	vars map[string]string
+}
+
+func (w *watchVars) Lookup(key string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	value, ok := w.vars[key]
+	return value, ok
+}
+
+func (w *watchVars) set(key, value string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.vars[key] = value
+}
+
+// reloadVars is a Lookuper returning the variables of first the first time
+// each is looked up and those of next after that.
+type reloadVars struct {
+	mu     sync.Mutex
+	looked map[string]bool
+
+	first, next Map
+}
+
+func (r *reloadVars) Lookup(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.looked == nil {
+		r.looked = map[string]bool{}
+	}
+	if !r.looked[key] {
+		r.looked[key] = true
+		return r.first.Lookup(key)
+	}
+	return r.next.Lookup(key)
+}
+
+func TestHolderWatch(t *testing.T) {
+	vars := &watchVars{vars: map[string]string{"HOST": "localhost", "PORT": "80"}}
+	h, err := NewHolder[watchConfig](WithLookuper(vars), WithHermetic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type event struct {
+		diff Diff
+		err  error
+	}
+	events := make(chan event)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- h.Watch(ctx, time.Millisecond, func(diff Diff, err error) {
+			events <- event{diff, err}
+		})
+	}()
+
+	vars.set("PORT", "8080")
+	e := <-events
+	if e.err != nil {
+		t.Fatal(e.err)
+	}
+	if want := (Diff{{Field: "Port", Env: "PORT", Old: 80, New: 8080}}); !reflect.DeepEqual(e.diff, want) {
+		t.Errorf("expected %v, found %v", want, e.diff)
+	}
+	if cfg := h.Load(); cfg.Port != 8080 || cfg.Host != "localhost" {
+		t.Errorf("unexpected config %+v", *cfg)
+	}
+
+	vars.set("PORT", "invalid")
+	e = <-events
+	var parseErr ParseError
+	if !errors.As(e.err, &parseErr) {
+		t.Errorf("expected a ParseError, found %v", e.err)
+	}
+	if e.diff != nil {
+		t.Errorf("expected no changes with an error, found %v", e.diff)
+	}
+	if cfg := h.Load(); cfg.Port != 8080 {
+		t.Errorf("expected the failed reload to keep the config, found %+v", *cfg)
+	}
+
+	cancel()
+	for {
+		select {
+		case <-events:
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, found %v", err)
+			}
+			return
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	type config struct {
+		Port  int    `env:"PORT"`
+		Token string `env:"TOKEN"`
+	}
+
+	fsys := fstest.MapFS{"a": {Data: []byte("first")}, "b": {Data: []byte("second")}}
+	vars := &reloadVars{
+		first: Map{"PORT": "80", "TOKEN_FILE": "/a"},
+		next:  Map{"PORT": "8080", "TOKEN_FILE": "/b"},
+	}
+
+	var cfg config
+	var diffs []Diff
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Watch(ctx, &cfg, time.Millisecond, func(diff Diff, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		diffs = append(diffs, diff)
+		if cfg.Token != "second" || cfg.Port != 8080 {
+			t.Errorf("expected the config to be replaced, found %+v", cfg)
+		}
+		cancel()
+	}, WithLookuper(vars), WithHermetic(), WithFS(fsys), WithFileFallback())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, found %v", err)
+	}
+
+	want := []Diff{{
+		{Field: "Port", Env: "PORT", Old: 80, New: 8080},
+		{Field: "Token", Env: "TOKEN", Old: Redacted, New: Redacted},
+	}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("expected %v, found %v", want, diffs)
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	type nested struct {
+		Inner *watchConfig `envPrefix:"INNER_"`
+	}
+	type secretConfig struct {
+		Token string `env:"TOKEN,secret"`
+		Key   string `env:"KEY,file"`
+	}
+
+	tests := []struct {
+		name      string
+		old, next interface{}
+		secrets   map[string]bool
+		want      Diff
+	}{
+		{
+			name: "unchanged",
+			old:  &watchConfig{Host: "a", Port: 1},
+			next: &watchConfig{Host: "a", Port: 1},
+		},
+		{
+			name: "changed",
+			old:  &watchConfig{Host: "a", Port: 1},
+			next: &watchConfig{Host: "b", Port: 1},
+			want: Diff{{Field: "Host", Env: "HOST", Old: "a", New: "b"}},
+		},
+		{
+			name: "added",
+			old:  &nested{},
+			next: &nested{Inner: &watchConfig{Host: "a"}},
+			want: Diff{
+				{Field: "Inner.Host", Env: "INNER_HOST", New: "a"},
+				{Field: "Inner.Port", Env: "INNER_PORT", New: 0},
+			},
+		},
+		{
+			name: "removed",
+			old:  &nested{Inner: &watchConfig{Port: 2}},
+			next: &nested{},
+			want: Diff{
+				{Field: "Inner.Host", Env: "INNER_HOST", Old: ""},
+				{Field: "Inner.Port", Env: "INNER_PORT", Old: 2},
+			},
+		},
+		{
+			name: "secret",
+			old:  &secretConfig{Token: "a"},
+			next: &secretConfig{Token: "b", Key: "k"},
+			want: Diff{
+				{Field: "Token", Env: "TOKEN", Old: Redacted, New: Redacted},
+				{Field: "Key", Env: "KEY", Old: "", New: Redacted},
+			},
+		},
+		{
+			name:    "read from a file",
+			old:     &watchConfig{Host: "a"},
+			next:    &watchConfig{Host: "b"},
+			secrets: map[string]bool{"Host": true},
+			want:    Diff{{Field: "Host", Env: "HOST", Old: Redacted, New: Redacted}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := diffValues(reflect.ValueOf(tt.old).Elem(), reflect.ValueOf(tt.next).Elem(), Options{}, tt.secrets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(diff, tt.want) {
+				t.Errorf("expected %v, found %v", tt.want, diff)
+			}
+		})
+	}
+}